@@ -1,20 +1,25 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"math/big"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/dog4ik/philmotecha/db"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type Database struct {
 	Queries db.Queries
+	Pool    *pgxpool.Pool
 }
 
 func json_response(w http.ResponseWriter, body any, status int) {
@@ -42,11 +47,36 @@ func error_response(w http.ResponseWriter, message string, status int) {
 	}
 }
 
+// with_tx runs f inside a transaction that is committed when f succeeds
+func (self *Database) with_tx(ctx context.Context, f func(queries *db.Queries) error) error {
+	tx, err := self.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = f(self.Queries.WithTx(tx))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func parse_path_id(r *http.Request) (int32, error) {
+	path_id := r.PathValue("id")
+	id, err := strconv.ParseInt(path_id, 10, 32)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("Could not parse id param")
+	}
+	return int32(id), nil
+}
+
 type DetailedActor struct {
-	Birth  pgtype.Date   `json:"birth"`
-	Name   string        `json:"name"`
-	Gender db.GenderType `json:"gender"`
-	Movies []ActorMovie  `json:"movies"`
+	ID     int32              `json:"id"`
+	Birth  pgtype.Date        `json:"birth"`
+	Name   string             `json:"name"`
+	Gender db.GenderType      `json:"gender"`
+	Movies []FilmographyMovie `json:"movies"`
 }
 
 type ActorMovie struct {
@@ -56,19 +86,44 @@ type ActorMovie struct {
 	ReleaseDate *string `json:"release_date"`
 }
 
+// FilmographyMovie is a movie the actor played in
+type FilmographyMovie struct {
+	ActorMovie
+	Characters   []string `json:"characters"`
+	BillingOrder *int32   `json:"billing_order"`
+	Uncredited   bool     `json:"uncredited"`
+}
+
+type idCursor struct {
+	ID int32 `json:"id"`
+}
+
 // ListActors lists all existing actors
 //
 //	@Summary		List actors
-//	@Description	get actors
+//	@Description	get actors page by page
 //	@Tags			actors
 //	@Produce		json
-//	@Success		200	{array}		DetailedActor
-//	@Failure		401	{object}	api.ServerError
-//	@Failure		500	{object}	api.ServerError
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	api.Page[api.DetailedActor]
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
 //	@Router			/list_actors [get]
 //	@Security		JwtAuth
 func (self *Database) ListActors(w http.ResponseWriter, r *http.Request) {
-	actors, err := self.Queries.ListActors(r.Context())
+	var cursor idCursor
+	limit, _, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actors, err := self.Queries.ListActors(r.Context(), db.ListActorsParams{
+		AfterID:   cursor.ID,
+		PageLimit: limit + 1,
+	})
 	if err != nil {
 		log.Printf("ERROR: Failed to list all actors {%s}", err)
 		error_response(w, "failed to list all actors", http.StatusInternalServerError)
@@ -76,84 +131,279 @@ func (self *Database) ListActors(w http.ResponseWriter, r *http.Request) {
 	}
 	var out []DetailedActor
 	for _, v := range actors {
-		var movies []ActorMovie
-    detailed_actor := DetailedActor{
+		var movies []FilmographyMovie
+		detailed_actor := DetailedActor{
+			ID:     v.ID,
 			Birth:  v.Birth,
 			Name:   v.Name,
 			Gender: v.Gender,
-			Movies: []ActorMovie{},
+			Movies: []FilmographyMovie{},
 		}
 		err = json.Unmarshal(v.Movies, &movies)
-    if err != nil {
-      detailed_actor.Movies = movies
-    }
+		if err == nil && movies != nil {
+			detailed_actor.Movies = movies
+		}
 		out = append(out, detailed_actor)
 	}
-	if actors == nil {
-		emptySlice := []string{}
-		json_response(w, emptySlice, http.StatusOK)
+
+	page, err := new_page(out, limit, func(last DetailedActor) any {
+		return idCursor{ID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "failed to list all actors", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, page, http.StatusOK)
+}
+
+func actor_movie(movie db.Movie) ActorMovie {
+	out := ActorMovie{
+		Title: movie.Title,
+		Id:    movie.ID,
+	}
+	if movie.Description.Valid {
+		out.Plot = &movie.Description.String
+	}
+	if movie.ReleaseDate.Valid {
+		release_date := movie.ReleaseDate.Time.Format(time.DateOnly)
+		out.ReleaseDate = &release_date
+	}
+	return out
+}
+
+// GetActor
+//
+//	@Summary		Get actor
+//	@Description	get actor with filmography, crew lists movies the person worked on in other roles
+//	@Tags			actors
+//	@Produce		json
+//	@Param			id	path		int	true	"Actor ID"
+//	@Success		200	{object}	api.DetailedPerson
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/actors/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) GetActor(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	actor, err := self.Queries.GetActor(r.Context(), id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "Actor not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to get actor: {%s}", err)
+		error_response(w, "Failed to get actor", http.StatusInternalServerError)
 		return
 	}
+
+	movies, err := self.Queries.ListActorFilmography(r.Context(), id)
+	if err != nil {
+		log.Printf("ERROR: Failed to list actor movies: {%s}", err)
+		error_response(w, "Failed to get actor", http.StatusInternalServerError)
+		return
+	}
+
+	crew, err := list_crew_movies(r.Context(), &self.Queries, id)
+	if err != nil {
+		log.Printf("ERROR: Failed to list crew movies: {%s}", err)
+		error_response(w, "Failed to get actor", http.StatusInternalServerError)
+		return
+	}
+
+	out := DetailedPerson{
+		DetailedActor: DetailedActor{
+			ID:     actor.ID,
+			Birth:  actor.Birth,
+			Name:   actor.Name,
+			Gender: actor.Gender,
+			Movies: []FilmographyMovie{},
+		},
+		Crew: crew,
+	}
+	for _, movie := range movies {
+		filmography_movie := FilmographyMovie{
+			ActorMovie: actor_movie(movie.Movie),
+			Characters: movie.Characters,
+			Uncredited: movie.Uncredited,
+		}
+		if filmography_movie.Characters == nil {
+			filmography_movie.Characters = []string{}
+		}
+		if movie.BillingOrder.Valid {
+			filmography_movie.BillingOrder = &movie.BillingOrder.Int32
+		}
+		out.Movies = append(out.Movies, filmography_movie)
+	}
 	json_response(w, out, http.StatusOK)
 }
 
+type movieCursor struct {
+	Sort        string         `json:"s"`
+	ID          int32          `json:"id"`
+	Title       string         `json:"t"`
+	ReleaseDate pgtype.Date    `json:"d"`
+	Rating      pgtype.Numeric `json:"r"`
+}
+
+// MoviePage is a page of the movie listing.
+// The first page also counts movies of every genre that pass the filters.
+type MoviePage struct {
+	Page[ListedMovie]
+	GenreCounts []GenreCount `json:"genre_counts,omitempty"`
+}
+
 // List movies lists all existing movies
 //
 //	@Summary		List movies
-//	@Description	get movies
+//	@Description	get movies page by page, in_watchlist and watched are set for the request user
 //	@Tags			movies
 //	@Produce		json
-//	@Param			sort_type	query		string	false	"Sort direction"	Enums(desc, asc)			default(desc)
-//	@Param			sort_by		query		string	false	"Sort property"		Enums(rating, title, date)	default(rating)
-//	@Success		200			{array}		db.Actor
+//	@Param			sort		query		string	false	"Comma separated sort properties (rating, title, date), - prefix means descending order. Ties are broken by id"	example(-rating,title)
+//	@Param			nulls		query		string	false	"Placement of movies with an empty sort property"	Enums(first, last)	default(last)
+//	@Param			sort_type	query		string	false	"Sort direction, used when sort is omitted"	Enums(desc, asc)			default(desc)
+//	@Param			sort_by		query		string	false	"Sort property, used when sort is omitted"		Enums(rating, title, date)	default(rating)
+//	@Param			limit		query		int		false	"Page size"			minimum(1)	maximum(500)	default(50)
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Param			released_after	query	string	false	"Movies released on or after the date"	format(date)
+//	@Param			released_before	query	string	false	"Movies released on or before the date"	format(date)
+//	@Param			min_rating		query	number	false	"Minimum rating"	minimum(0)	maximum(10)
+//	@Param			max_rating		query	number	false	"Maximum rating"	minimum(0)	maximum(10)
+//	@Param			has_description	query	bool	false	"Movies with or without description"
+//	@Param			actor			query	[]int	false	"Movies featuring all of the actors"	collectionFormat(multi)
+//	@Param			director		query	[]int	false	"Movies directed by all of the people"	collectionFormat(multi)
+//	@Param			genre			query	[]int	false	"Movies in all of the genres"	collectionFormat(multi)
+//	@Param			title_prefix	query	string	false	"Case insensitive title prefix"
+//	@Success		200			{object}	api.MoviePage
+//	@Failure		400			{object}	api.ServerError
 //	@Failure		401			{object}	api.ServerError
 //	@Failure		500			{object}	api.ServerError
 //	@Router			/list_movies [get]
 //	@Security		JwtAuth
 func (self *Database) ListMovies(w http.ResponseWriter, r *http.Request) {
-	sort_by := r.URL.Query().Get("sort_by")
-	sort_type := r.URL.Query().Get("sort_type")
-	switch sort_by {
-	case "rating", "":
-		sort_by = "rating"
-	case "title":
-		sort_by = "title"
-	case "date":
-		sort_by = "release_date"
-	default:
-		error_response(w, fmt.Sprintf("parameter %s is not recognized", sort_by), http.StatusBadRequest)
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	movie_sort, err := parse_movie_sort(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	switch sort_type {
-	case "", "desc":
-		sort_type = "ASC"
-	case "asc":
-		sort_type = "ASC"
-	default:
-		error_response(w, fmt.Sprintf("parameter %s is not recognized", sort_by), http.StatusBadRequest)
+	sort := movie_sort.String()
+	var cursor movieCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if has_cursor && cursor.Sort != sort {
+		error_response(w, "cursor belongs to a different sort order", http.StatusBadRequest)
 		return
 	}
 
-	var err error
-	var movies []db.Movie
-	if sort_type == "DESC" {
-		movies, err = self.Queries.ListMoviesDesc(r.Context(), sort_by)
-	} else if sort_type == "ASC" {
-		movies, err = self.Queries.ListMoviesAsc(r.Context(), sort_by)
+	params := db.ListMoviesParams{PageLimit: limit + 1}
+	movie_sort.apply(&params)
+	err = parse_movie_filters(r, &params)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if has_cursor {
+		params.CursorID = pgtype.Int4{Int32: cursor.ID, Valid: true}
+		params.CursorTitle = pgtype.Text{String: cursor.Title, Valid: true}
+		params.CursorReleaseDate = cursor.ReleaseDate
+		params.CursorRating = cursor.Rating
 	}
 
+	rows, err := list_movies(r.Context(), &self.Queries, movie_sort, params)
 	if err != nil {
-		log.Printf("ERROR: Failed to list all actors {%s}", err)
+		log.Printf("ERROR: Failed to list all movies {%s}", err)
+		error_response(w, "Server database error", http.StatusInternalServerError)
+		return
+	}
+	movies, err := listed_movies(r.Context(), &self.Queries, user.ID, rows)
+	if err != nil {
+		log.Printf("ERROR: Failed to list watchlist flags {%s}", err)
 		error_response(w, "Server database error", http.StatusInternalServerError)
 		return
 	}
 
-	if movies == nil {
-		json_response(w, []string{}, http.StatusOK)
+	page, err := new_page(movies, limit, func(last ListedMovie) any {
+		return movieCursor{
+			Sort:        sort,
+			ID:          last.ID,
+			Title:       last.Title,
+			ReleaseDate: last.ReleaseDate,
+			Rating:      last.Rating,
+		}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Server database error", http.StatusInternalServerError)
 		return
 	}
-	json_response(w, movies, http.StatusOK)
+
+	out := MoviePage{Page: page}
+	if !has_cursor {
+		out.GenreCounts, err = genre_counts(r.Context(), &self.Queries, params)
+		if err != nil {
+			log.Printf("ERROR: Failed to count movie genres {%s}", err)
+			error_response(w, "Server database error", http.StatusInternalServerError)
+			return
+		}
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+// GetMovie
+//
+//	@Summary		Get movie
+//	@Description	get movie with its cast
+//	@Tags			movies
+//	@Produce		json
+//	@Param			id	path		int	true	"Movie ID"
+//	@Success		200	{object}	api.DetailedMovie
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/movies/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) GetMovie(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	movie, err := self.Queries.GetMovie(r.Context(), id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "Movie not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to get movie: {%s}", err)
+		error_response(w, "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+
+	out, err := detailed_movie(r.Context(), &self.Queries, movie)
+	if err != nil {
+		log.Printf("ERROR: Failed to list movie cast: {%s}", err)
+		error_response(w, "Failed to get movie", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, out, http.StatusOK)
 }
 
 // AddActor
@@ -164,7 +414,7 @@ func (self *Database) ListMovies(w http.ResponseWriter, r *http.Request) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			actor	body		db.CreateActorParams	true	"Add actor"
-//	@Success		201		{object}	db.Actor
+//	@Success		201		{object}	db.Person
 //	@Failure		400		{object}	api.ServerError
 //	@Failure		401		{object}	api.ServerError
 //	@Failure		403		{object}	api.ServerError
@@ -188,26 +438,41 @@ func (self *Database) InsertActor(w http.ResponseWriter, r *http.Request) {
 	json_response(w, new_actor, http.StatusCreated)
 }
 
+// Movies without a language are searched as english ones
+const defaultLanguage = "en"
+
+// validate_language checks that the language is a two letter ISO 639-1 code
+func validate_language(language string) error {
+	if len(language) != 2 || language[0] < 'a' || language[0] > 'z' || language[1] < 'a' || language[1] > 'z' {
+		return fmt.Errorf("Language must be a two letter ISO 639-1 code like en")
+	}
+	return nil
+}
+
 type NewMovieParams struct {
 	Title       string         `json:"title" minLength:"1" maxLength:"150" example:"Inception"`
 	Description pgtype.Text    `json:"description" maxLength:"1000" example:"Boring movie about planets"`
 	ReleaseDate pgtype.Date    `json:"release_date"`
 	Rating      pgtype.Numeric `json:"rating" minimum:"0" maximum:"10"`
-	Actors      []int32        `json:"actors"`
+	Language    string         `json:"language" minLength:"2" maxLength:"2" example:"en" default:"en"`
+	Actors      []CastEntry    `json:"actors"`
+	Genres      []int32        `json:"genres"`
 }
 
 // AddMovie
 //
 //	@Summary		Add an movie
-//	@Description	Add a movie. Any invalid actor id will be ingored
+//	@Description	Add a movie with its cast and genres. Unknown actor ids are ignored unless strict mode is requested, unknown genre ids are always rejected
 //	@Tags			movies
 //	@Accept			json
 //	@Produce		json
 //	@Param			movie	body		api.NewMovieParams	true	"Add movie"
-//	@Success		201		{object}	db.Movie
+//	@Param			strict	query		bool				false	"Reject unknown actor ids"	default(false)
+//	@Success		201		{object}	api.DetailedMovie
 //	@Failure		400		{object}	api.ServerError
 //	@Failure		401		{object}	api.ServerError
 //	@Failure		403		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError	"Unknown actors in strict mode or unknown genres"
 //	@Failure		500		{object}	api.ServerError
 //	@Router			/add_movie [post]
 //	@Security		JwtAuth
@@ -219,6 +484,12 @@ func (self *Database) InsertMovie(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	strict, err := parse_strict(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if len(payload.Description.String) > 1000 {
 		error_response(w, "Description length must be less then 1000 characters", http.StatusBadRequest)
 		return
@@ -227,31 +498,61 @@ func (self *Database) InsertMovie(w http.ResponseWriter, r *http.Request) {
 		error_response(w, "Movie title length must be between 1 and 150 characters", http.StatusBadRequest)
 		return
 	}
+	if payload.Language == "" {
+		payload.Language = defaultLanguage
+	}
+	if err := validate_language(payload.Language); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validate_cast(payload.Actors); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var response DetailedMovie
+	err = self.with_tx(r.Context(), func(queries *db.Queries) error {
+		cast, err := existing_cast(r.Context(), queries, payload.Actors, strict)
+		if err != nil {
+			return err
+		}
 
-	new_movie, err := self.Queries.CreateMovie(r.Context(), db.CreateMovieParams{
-		Title:       payload.Title,
-		Description: payload.Description,
-		ReleaseDate: payload.ReleaseDate,
-		Rating:      payload.Rating,
+		new_movie, err := queries.CreateMovie(r.Context(), db.CreateMovieParams{
+			Title:       payload.Title,
+			Description: payload.Description,
+			ReleaseDate: payload.ReleaseDate,
+			Rating:      payload.Rating,
+			Language:    payload.Language,
+		})
+		if err != nil {
+			return err
+		}
+
+		err = create_cast(r.Context(), queries, new_movie.ID, cast)
+		if err != nil {
+			return err
+		}
+
+		err = set_movie_genres(r.Context(), queries, new_movie.ID, payload.Genres)
+		if err != nil {
+			return err
+		}
+
+		response, err = detailed_movie(r.Context(), queries, new_movie)
+		return err
 	})
+
+	var unknown unknownIdsError
+	if errors.As(err, &unknown) {
+		unknown_ids_response(w, unknown)
+		return
+	}
 	if err != nil {
 		log.Printf("ERROR: Failed to create Movie: {%s}", err)
 		error_response(w, "failed to insert Movie", http.StatusInternalServerError)
 		return
 	}
-	for _, actor_id := range payload.Actors {
-		err = self.Queries.CreateMovieActor(
-			r.Context(),
-			db.CreateMovieActorParams{
-				MovieID: new_movie.ID,
-				ActorID: actor_id,
-			})
-		if err != nil {
-			log.Printf("ERROR: Failed connect actor with movie: {%s}", err)
-			continue
-		}
-	}
-	json_response(w, new_movie, http.StatusCreated)
+	json_response(w, response, http.StatusCreated)
 }
 
 type ActorPayload struct {
@@ -319,6 +620,9 @@ type MoviePayload struct {
 	Rating       pgtype.Numeric `json:"rating" minimum:"0" maximum:"10"`
 	Release_date pgtype.Date    `json:"release_date"`
 	Title        pgtype.Text    `json:"title" minLength:"1" maxLength:"150" example:"Inception"`
+	Language     pgtype.Text    `json:"language" minLength:"2" maxLength:"2" example:"ru"`
+	// Genres replace genres of the movie when present
+	Genres *[]int32 `json:"genres"`
 }
 
 func (c MoviePayload) Validate() error {
@@ -331,6 +635,9 @@ func (c MoviePayload) Validate() error {
 	if c.Rating.Valid && (c.Rating.Int.Cmp(big.NewInt(10)) < 1 || c.Rating.Int.Cmp(big.NewInt(0)) > -1) {
 		return fmt.Errorf("Rating must be a number between 0 and 10")
 	}
+	if c.Language.Valid {
+		return validate_language(c.Language.String)
+	}
 	return nil
 }
 
@@ -348,6 +655,7 @@ func (c MoviePayload) Validate() error {
 //	@Failure		401	{object}	api.ServerError
 //	@Failure		403	{object}	api.ServerError
 //	@Failure		404	{object}	api.ServerError
+//	@Failure		422	{object}	api.UnknownIdsError
 //	@Failure		500	{object}	api.ServerError
 //	@Router			/update_movie/{id} [patch]
 //	@Security		JwtAuth
@@ -369,10 +677,21 @@ func (self *Database) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 		error_response(w, fmt.Sprintf("Could not validate payload: %s", err), http.StatusBadRequest)
 		return
 	}
-	err = self.Queries.UpdateMovie(r.Context(), db.UpdateMovieParams{
-		ID:          int32(id),
-		Title:       payload.Title,
-		Description: payload.Description,
+	err = self.with_tx(r.Context(), func(queries *db.Queries) error {
+		_, err := queries.LockMovie(r.Context(), int32(id))
+		if err != nil {
+			return err
+		}
+		err = queries.UpdateMovie(r.Context(), db.UpdateMovieParams{
+			ID:          int32(id),
+			Title:       payload.Title,
+			Description: payload.Description,
+			Language:    payload.Language,
+		})
+		if err != nil || payload.Genres == nil {
+			return err
+		}
+		return set_movie_genres(r.Context(), queries, int32(id), *payload.Genres)
 	})
 
 	if err != nil {
@@ -380,6 +699,11 @@ func (self *Database) UpdateMovie(w http.ResponseWriter, r *http.Request) {
 			error_response(w, err.Error(), http.StatusNotFound)
 			return
 		}
+		var unknown unknownIdsError
+		if errors.As(err, &unknown) {
+			unknown_ids_response(w, unknown)
+			return
+		}
 		log.Printf("ERROR: Failed to update movie: {%s}", err)
 		error_response(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -457,45 +781,6 @@ func (self *Database) DeleteMovie(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// SearchMovie
-//
-//	@Summary		Search lookup by fragment of movie's name or fragment of actor's name
-//	@Description	Search by query
-//	@Tags			movies
-//	@Accept			json
-//	@Produce		json
-//	@Param			q query	string	true	"search by query"
-//	@Success		200	{array}		db.Movie
-//	@Failure		400	{object}	api.ServerError
-//	@Failure		401	{object}	api.ServerError
-//	@Failure		500	{object}	api.ServerError
-//	@Router			/search [get]
-//	@Security		JwtAuth
-func (self *Database) SearchMovie(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	movies, err := self.Queries.SearchMovie(r.Context(), db.SearchMovieParams{
-		ToTsquery: fmt.Sprintf("%s:*", query),
-		Lower:     fmt.Sprintf("%%%s%%", query),
-	})
-
-	if err != nil {
-		if err == pgx.ErrNoRows {
-			emptySlice := []int{}
-			json_response(w, emptySlice, http.StatusOK)
-			return
-		}
-		log.Printf("ERROR: Failed to search movie: %s", err)
-		error_response(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	if movies == nil {
-		json_response(w, []string{}, http.StatusOK)
-		return
-	}
-	json_response(w, movies, http.StatusOK)
-}
-
 func (self *Database) ClearDb(w http.ResponseWriter, r *http.Request) {
 	err := self.Queries.ClearDatabase(r.Context())
 	if err != nil {
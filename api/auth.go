@@ -2,16 +2,20 @@ package api
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/dog4ik/philmotecha/db"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -21,8 +25,10 @@ type UserPayload struct {
 }
 
 type jwtPayload struct {
-	UserId int32 `json:"user_id"`
-	Exp    int64 `json:"exp"`
+	UserId   int32  `json:"user_id"`
+	Exp      int64  `json:"exp"`
+	IssuedAt int64  `json:"iat"`
+	FamilyId string `json:"fam,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -32,12 +38,24 @@ type AuthResponse struct {
 }
 
 func extractUser(r *http.Request, queries *db.Queries) (db.Appuser, error) {
-	user_id, err := GetAuthenticatedUser(r)
+	claims, err := getAccessClaims(r)
+	if err != nil {
+		return db.Appuser{}, fmt.Errorf("unauthorized")
+	}
+
+	if claims.ID == "" {
+		return db.Appuser{}, fmt.Errorf("unauthorized")
+	}
+	revoked, err := isTokenRevoked(r.Context(), queries, claims.ID)
 	if err != nil {
+		log.Printf("ERROR: Failed to check token revocation: {%s}", err)
+		return db.Appuser{}, fmt.Errorf("internalerror")
+	}
+	if revoked {
 		return db.Appuser{}, fmt.Errorf("unauthorized")
 	}
 
-	user, err := queries.GetUserById(context.Background(), user_id)
+	user, err := queries.GetUserById(r.Context(), claims.UserId)
 
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -45,23 +63,60 @@ func extractUser(r *http.Request, queries *db.Queries) (db.Appuser, error) {
 		}
 		return db.Appuser{}, fmt.Errorf("internalerror")
 	}
+	if claims.IssuedAt < user.TokensValidAfter.Time.UnixMilli() {
+		return db.Appuser{}, fmt.Errorf("unauthorized")
+	}
+	if user.Disabled {
+		return db.Appuser{}, fmt.Errorf("disabled")
+	}
 	return user, nil
 }
 
-func GetAuthenticatedUser(r *http.Request) (int32, error) {
+func auth_error_response(w http.ResponseWriter, err error) {
+	switch err.Error() {
+	case "notfound":
+		error_response(w, "User not found", http.StatusNotFound)
+	case "unauthorized":
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+	case "disabled":
+		error_response(w, "User account is disabled", http.StatusForbidden)
+	default:
+		error_response(w, "Unknown server error", http.StatusInternalServerError)
+	}
+}
+
+type userContextKey struct{}
+
+// requestUser returns the user attached to the request by the auth middlewares
+func requestUser(r *http.Request) (db.Appuser, bool) {
+	user, ok := r.Context().Value(userContextKey{}).(db.Appuser)
+	return user, ok
+}
+
+func withRequestUser(r *http.Request, user db.Appuser) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), userContextKey{}, user))
+}
+
+func getAccessClaims(r *http.Request) (*jwtPayload, error) {
 	auth := r.Header.Get("Authorization")
 
 	if auth == "" {
-		return 0, fmt.Errorf("Auth header is absent")
+		return nil, fmt.Errorf("Auth header is absent")
 	}
 
 	bearer := "Bearer "
-	auth = auth[len(bearer):]
-	user_id, err := VerifyAccessToken(auth)
+	if !strings.HasPrefix(auth, bearer) {
+		return nil, fmt.Errorf("Auth header is not a bearer token")
+	}
+	return verifyAccessClaims(auth[len(bearer):])
+}
+
+func GetAuthenticatedUser(r *http.Request) (int32, error) {
+	claims, err := getAccessClaims(r)
 	if err != nil {
 		return 0, err
 	}
-	return user_id, nil
+	return claims.UserId, nil
 }
 
 type EnsureAdminAuth struct {
@@ -72,15 +127,7 @@ type EnsureAdminAuth struct {
 func (ea *EnsureAdminAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	user, err := extractUser(r, ea.queries)
 	if err != nil {
-		if err.Error() == "notfound" {
-			error_response(w, "User not found", http.StatusNotFound)
-			return
-		}
-		if err.Error() == "unauthorized" {
-			error_response(w, "User is not authorized", http.StatusUnauthorized)
-			return
-		}
-		error_response(w, "Unknown server error", http.StatusInternalServerError)
+		auth_error_response(w, err)
 		return
 	}
 	if user.Role != db.UserRoleAdmin {
@@ -88,7 +135,7 @@ func (ea *EnsureAdminAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ea.handler.ServeHTTP(w, r)
+	ea.handler.ServeHTTP(w, withRequestUser(r, user))
 }
 
 type EnsureAnyAuth struct {
@@ -97,21 +144,13 @@ type EnsureAnyAuth struct {
 }
 
 func (ea *EnsureAnyAuth) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	_, err := extractUser(r, ea.queries)
+	user, err := extractUser(r, ea.queries)
 	if err != nil {
-		if err.Error() == "notfound" {
-			error_response(w, "User not found", http.StatusNotFound)
-			return
-		}
-		if err.Error() == "unauthorized" {
-			error_response(w, "User is not authorized", http.StatusUnauthorized)
-			return
-		}
-		error_response(w, "Unknown server error", http.StatusInternalServerError)
+		auth_error_response(w, err)
 		return
 	}
 
-	ea.handler.ServeHTTP(w, r)
+	ea.handler.ServeHTTP(w, withRequestUser(r, user))
 }
 
 func NewEnsureAnyAuth(queries *db.Queries) func(http.HandlerFunc) *EnsureAnyAuth {
@@ -126,11 +165,114 @@ func NewEnsureAdminAuth(queries *db.Queries) func(http.HandlerFunc) *EnsureAdmin
 	}
 }
 
-func GenerateAccessToken(id int32) (string, error) {
+type collectionContextKey struct{}
+
+// requestCollection returns the collection attached to the request by EnsureCollectionAccess
+func requestCollection(r *http.Request) (db.Collection, bool) {
+	collection, ok := r.Context().Value(collectionContextKey{}).(db.Collection)
+	return collection, ok
+}
+
+// EnsureCollectionAccess loads the collection from the path and checks its visibility.
+// Public collections can be read without authentication, other collections only by their owner and admins.
+// Collections can be changed only by their owner.
+type EnsureCollectionAccess struct {
+	handler http.HandlerFunc
+	queries *db.Queries
+	write   bool
+}
+
+func (ea *EnsureCollectionAccess) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var user db.Appuser
+	authenticated := ea.write || r.Header.Get("Authorization") != ""
+	if authenticated {
+		var err error
+		user, err = extractUser(r, ea.queries)
+		if err != nil {
+			auth_error_response(w, err)
+			return
+		}
+		r = withRequestUser(r, user)
+	}
+
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	collection, err := ea.queries.GetCollection(r.Context(), id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to get collection: {%s}", err)
+		error_response(w, "Failed to get collection", http.StatusInternalServerError)
+		return
+	}
+
+	// Hidden collections are reported as missing so their ids do not leak
+	owner := authenticated && collection.UserID == user.ID
+	visible := owner || collection.Visibility == db.CollectionVisibilityPublic ||
+		(authenticated && user.Role == db.UserRoleAdmin)
+	if !visible {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if ea.write && !owner {
+		error_response(w, "This collection belongs to another user", http.StatusForbidden)
+		return
+	}
+
+	ea.handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), collectionContextKey{}, collection)))
+}
+
+func NewEnsureCollectionReadAccess(queries *db.Queries) func(http.HandlerFunc) *EnsureCollectionAccess {
+	return func(handlerToWrap http.HandlerFunc) *EnsureCollectionAccess {
+		return &EnsureCollectionAccess{handlerToWrap, queries, false}
+	}
+}
+
+func NewEnsureCollectionWriteAccess(queries *db.Queries) func(http.HandlerFunc) *EnsureCollectionAccess {
+	return func(handlerToWrap http.HandlerFunc) *EnsureCollectionAccess {
+		return &EnsureCollectionAccess{handlerToWrap, queries, true}
+	}
+}
+
+const accessTokenTTL = 30 * 24 * time.Hour
+const refreshTokenTTL = 365 * 24 * time.Hour
+
+func newTokenId() (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// RevocationTime returns the moment that invalidates access tokens issued before it.
+// It comes from the same clock and precision as the iat claim rather than from the database,
+// so clock skew between the two does not reject tokens issued right after a revocation.
+func RevocationTime() pgtype.Timestamptz {
+	return pgtype.Timestamptz{Time: time.Now().Truncate(time.Millisecond), Valid: true}
+}
+
+// GenerateAccessToken signs an access token that belongs to the refresh token family.
+// Every access token gets an unique jti so it can be revoked on its own.
+func GenerateAccessToken(id int32, family_id string) (string, error) {
 	key := []byte(os.Getenv("ACCESS_TOKEN"))
+	jti, err := newTokenId()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
 	claims := jwtPayload{
-		UserId: id,
-		Exp:    time.Now().Add(30 * 24 * time.Hour).UnixMilli(),
+		UserId:           id,
+		Exp:              now.Add(accessTokenTTL).UnixMilli(),
+		IssuedAt:         now.UnixMilli(),
+		FamilyId:         family_id,
+		RegisteredClaims: jwt.RegisteredClaims{ID: jti},
 	}
 	if len(key) == 0 {
 		return "", fmt.Errorf("access token env not set")
@@ -143,14 +285,18 @@ func GenerateAccessToken(id int32) (string, error) {
 	return s, nil
 }
 
-func GenerateRefreshToken(id int32) (string, error) {
+// GenerateRefreshToken signs a refresh token identified by jti.
+// The jti is the primary key of the RefreshToken row that tracks its usage.
+func GenerateRefreshToken(id int32, jti string, expires time.Time) (string, error) {
 	key := []byte(os.Getenv("REFRESH_TOKEN"))
 	if len(key) == 0 {
 		return "", fmt.Errorf("refresh token env not set")
 	}
 	claims := jwtPayload{
-		UserId: id,
-		Exp:    time.Now().Add(365 * 24 * time.Hour).UnixMilli(),
+		UserId:           id,
+		Exp:              expires.UnixMilli(),
+		IssuedAt:         time.Now().UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{ID: jti},
 	}
 	t := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	s, err := t.SignedString(key)
@@ -160,24 +306,90 @@ func GenerateRefreshToken(id int32) (string, error) {
 	return s, nil
 }
 
-func VerifyAccessToken(token string) (int32, error) {
+func parseToken(token string, key []byte) (*jwtPayload, error) {
 	res, err := jwt.ParseWithClaims(token, &jwtPayload{}, func(t *jwt.Token) (interface{}, error) {
 		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", t.Header["alg"])
 		}
-		return []byte(os.Getenv("ACCESS_TOKEN")), nil
+		return key, nil
 
 	})
 	if err != nil {
-		return 0, fmt.Errorf("failed to parse token: %v", err)
+		return nil, fmt.Errorf("failed to parse token: %v", err)
 	}
 	if claims, ok := res.Claims.(*jwtPayload); ok && res.Valid {
 		if claims.Exp-time.Now().UnixMilli() < 0 {
-			return 0, fmt.Errorf("token expired")
+			return nil, fmt.Errorf("token expired")
 		}
-		return claims.UserId, nil
+		return claims, nil
+	}
+	return nil, fmt.Errorf("error getting claims")
+}
+
+func verifyAccessClaims(token string) (*jwtPayload, error) {
+	return parseToken(token, []byte(os.Getenv("ACCESS_TOKEN")))
+}
+
+func VerifyAccessToken(token string) (int32, error) {
+	claims, err := verifyAccessClaims(token)
+	if err != nil {
+		return 0, err
+	}
+	return claims.UserId, nil
+}
+
+// VerifyRefreshToken returns the owner and the jti of a refresh token
+func VerifyRefreshToken(token string) (int32, string, error) {
+	claims, err := parseToken(token, []byte(os.Getenv("REFRESH_TOKEN")))
+	if err != nil {
+		return 0, "", err
+	}
+	if claims.ID == "" {
+		return 0, "", fmt.Errorf("refresh token has no jti")
+	}
+	return claims.UserId, claims.ID, nil
+}
+
+// issueTokens creates a new access/refresh pair.
+// The refresh token is persisted as a member of the given family.
+func issueTokens(ctx context.Context, queries *db.Queries, user_id int32, family_id string) (AuthResponse, error) {
+	access_token, err := GenerateAccessToken(user_id, family_id)
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("failed to generate access token: %w", err)
+	}
+
+	jti, err := newTokenId()
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("failed to generate token id: %w", err)
+	}
+	expires := time.Now().Add(refreshTokenTTL)
+	refresh_token, err := GenerateRefreshToken(user_id, jti, expires)
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	err = queries.CreateRefreshToken(ctx, db.CreateRefreshTokenParams{
+		ID:        jti,
+		FamilyID:  family_id,
+		UserID:    user_id,
+		ExpiresAt: pgtype.Timestamptz{Time: expires, Valid: true},
+	})
+	if err != nil {
+		return AuthResponse{}, fmt.Errorf("failed to save refresh token: %w", err)
+	}
+
+	return AuthResponse{
+		AccessToken:  access_token,
+		RefreshToken: refresh_token,
+	}, nil
+}
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), 14)
+	if err != nil {
+		return "", err
 	}
-	return 0, fmt.Errorf("error getting claims")
+	return string(hash), nil
 }
 
 // AddUser
@@ -188,7 +400,7 @@ func VerifyAccessToken(token string) (int32, error) {
 //	@Accept			json
 //	@Produce		json
 //	@Param			user	body		api.UserPayload	true	"Add user"
-//	@Success		201		{object}	db.Appuser
+//	@Success		201		{object}	api.UserInfo
 //	@Failure		400		{object}	api.ServerError
 //	@Failure		500		{object}	api.ServerError
 //	@Router			/add_user [post]
@@ -200,13 +412,18 @@ func (self *Database) InsertUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	passwordHash, err := bcrypt.GenerateFromPassword([]byte(payload.Password), 14)
+	passwordHash, err := HashPassword(payload.Password)
+	if err != nil {
+		log.Printf("ERROR: Failed to hash password: %s", err)
+		error_response(w, "Failed to create user", http.StatusInternalServerError)
+		return
+	}
 
 	new_user, err := self.Queries.CreateUser(
 		context.Background(),
 		db.CreateUserParams{
 			Username: payload.Username,
-			Password: string(passwordHash),
+			Password: passwordHash,
 			Role:     db.UserRoleUser,
 		})
 
@@ -215,7 +432,7 @@ func (self *Database) InsertUser(w http.ResponseWriter, r *http.Request) {
 		error_response(w, "Failed to create user", http.StatusInternalServerError)
 		return
 	}
-	json_response(w, new_user, http.StatusCreated)
+	json_response(w, userInfo(new_user), http.StatusCreated)
 }
 
 // Login
@@ -258,24 +475,186 @@ func (self *Database) LoginUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	access_token, err := GenerateAccessToken(user.ID)
-	if err != nil {
-		log.Printf("ERROR: Failed to generate access token: {%s}", err)
-		error_response(w, "Failed to generate access token", http.StatusInternalServerError)
+	if user.Disabled {
+		error_response(w, "User account is disabled", http.StatusForbidden)
 		return
 	}
 
-	refresh_token, err := GenerateRefreshToken(user.ID)
+	family_id, err := newTokenId()
 	if err != nil {
-		log.Printf("ERROR: Failed to generate refresh token: {%s}", err)
+		log.Printf("ERROR: Failed to generate token family: {%s}", err)
 		error_response(w, "Failed to generate refresh token", http.StatusInternalServerError)
 		return
 	}
 
-	response := AuthResponse{
-		AccessToken:  access_token,
-		RefreshToken: refresh_token,
+	response, err := issueTokens(r.Context(), &self.Queries, user.ID, family_id)
+	if err != nil {
+		log.Printf("ERROR: Failed to issue tokens: {%s}", err)
+		error_response(w, "Failed to issue tokens", http.StatusInternalServerError)
+		return
+	}
+
+	json_response(w, response, http.StatusOK)
+}
+
+type RefreshPayload struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh
+//
+//	@Summary		Refresh tokens
+//	@Description	Exchange a refresh token for a new access/refresh pair. Every refresh token can be used only once, presenting an already used token revokes the whole session
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			token	body		api.RefreshPayload	true	"Refresh token"
+//	@Success		200		{object}	api.AuthResponse
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/refresh [post]
+func (self *Database) RefreshUser(w http.ResponseWriter, r *http.Request) {
+	var payload RefreshPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user_id, jti, err := VerifyRefreshToken(payload.RefreshToken)
+	if err != nil {
+		error_response(w, "Refresh token is invalid", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := self.Queries.UseRefreshToken(r.Context(), jti)
+	if err != nil {
+		if err != pgx.ErrNoRows {
+			log.Printf("ERROR: Failed to use refresh token: {%s}", err)
+			error_response(w, "Internal database error", http.StatusInternalServerError)
+			return
+		}
+		stored, err := self.Queries.GetRefreshToken(r.Context(), jti)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				error_response(w, "Refresh token is not recognized", http.StatusUnauthorized)
+				return
+			}
+			log.Printf("ERROR: Failed to get refresh token: {%s}", err)
+			error_response(w, "Internal database error", http.StatusInternalServerError)
+			return
+		}
+		if !stored.Revoked {
+			log.Printf("WARN: Refresh token reuse detected for user %d, revoking family %s", stored.UserID, stored.FamilyID)
+			err = self.Queries.RevokeRefreshTokenFamily(r.Context(), stored.FamilyID)
+			if err != nil {
+				log.Printf("ERROR: Failed to revoke refresh token family: {%s}", err)
+				error_response(w, "Internal database error", http.StatusInternalServerError)
+				return
+			}
+		}
+		error_response(w, "Refresh token was revoked", http.StatusUnauthorized)
+		return
+	}
+
+	if token.UserID != user_id || token.ExpiresAt.Time.Before(time.Now()) {
+		error_response(w, "Refresh token is invalid", http.StatusUnauthorized)
+		return
+	}
+
+	user, err := self.Queries.GetUserById(r.Context(), token.UserID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to get user: {%s}", err)
+		error_response(w, "Internal database error", http.StatusInternalServerError)
+		return
+	}
+	if user.Disabled {
+		error_response(w, "User account is disabled", http.StatusForbidden)
+		return
+	}
+
+	response, err := issueTokens(r.Context(), &self.Queries, token.UserID, token.FamilyID)
+	if err != nil {
+		log.Printf("ERROR: Failed to issue tokens: {%s}", err)
+		error_response(w, "Failed to issue tokens", http.StatusInternalServerError)
+		return
 	}
 
 	json_response(w, response, http.StatusOK)
 }
+
+// Logout
+//
+//	@Summary		Logout
+//	@Description	Revoke the access token used for this request together with its refresh token family
+//	@Tags			users
+//	@Produce		json
+//	@Success		200
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/logout [post]
+//	@Security		JwtAuth
+func (self *Database) Logout(w http.ResponseWriter, r *http.Request) {
+	claims, err := getAccessClaims(r)
+	if err != nil {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = revokeToken(r.Context(), &self.Queries, claims)
+	if err != nil {
+		log.Printf("ERROR: Failed to revoke access token: {%s}", err)
+		error_response(w, "Failed to revoke access token", http.StatusInternalServerError)
+		return
+	}
+
+	if claims.FamilyId != "" {
+		err = self.Queries.RevokeRefreshTokenFamily(r.Context(), claims.FamilyId)
+		if err != nil {
+			log.Printf("ERROR: Failed to revoke refresh token family: {%s}", err)
+			error_response(w, "Failed to revoke refresh token", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// LogoutAll
+//
+//	@Summary		Logout everywhere
+//	@Description	Revoke every access and refresh token issued to the user
+//	@Tags			users
+//	@Produce		json
+//	@Success		200
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/logout_all [post]
+//	@Security		JwtAuth
+func (self *Database) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	user_id, err := GetAuthenticatedUser(r)
+	if err != nil {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	err = self.Queries.RevokeUserTokens(r.Context(), db.RevokeUserTokensParams{
+		ID:               user_id,
+		TokensValidAfter: RevocationTime(),
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to revoke user tokens: {%s}", err)
+		error_response(w, "Failed to revoke access tokens", http.StatusInternalServerError)
+		return
+	}
+
+	err = self.Queries.RevokeUserRefreshTokens(r.Context(), user_id)
+	if err != nil {
+		log.Printf("ERROR: Failed to revoke user refresh tokens: {%s}", err)
+		error_response(w, "Failed to revoke refresh tokens", http.StatusInternalServerError)
+		return
+	}
+}
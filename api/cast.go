@@ -0,0 +1,515 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// UnknownIdsError lists ids of an entity that do not exist
+type UnknownIdsError struct {
+	Message string  `json:"message" example:"Some of the actors do not exist"`
+	Entity  string  `json:"entity" example:"actor"`
+	Ids     []int32 `json:"ids"`
+}
+
+type unknownIdsError struct {
+	entity string
+	ids    []int32
+}
+
+func (e unknownIdsError) Error() string {
+	return fmt.Sprintf("unknown %s ids: %v", e.entity, e.ids)
+}
+
+func unknown_ids_response(w http.ResponseWriter, err unknownIdsError) {
+	json_response(w, UnknownIdsError{
+		Message: fmt.Sprintf("Some of the %ss do not exist", err.entity),
+		Entity:  err.entity,
+		Ids:     err.ids,
+	}, http.StatusUnprocessableEntity)
+}
+
+// cast_error_response reports errors of the cast operations
+func cast_error_response(w http.ResponseWriter, err error, not_found string) {
+	var unknown unknownIdsError
+	switch {
+	case err == pgx.ErrNoRows:
+		error_response(w, not_found, http.StatusNotFound)
+	case errors.As(err, &unknown):
+		unknown_ids_response(w, unknown)
+	default:
+		log.Printf("ERROR: Failed to update cast: {%s}", err)
+		error_response(w, "Failed to update cast", http.StatusInternalServerError)
+	}
+}
+
+// parse_strict reads the strict query flag that turns unknown actor ids into an error
+func parse_strict(r *http.Request) (bool, error) {
+	value := r.URL.Query().Get("strict")
+	if value == "" {
+		return false, nil
+	}
+	strict, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("parameter strict must be a boolean")
+	}
+	return strict, nil
+}
+
+// existing_ids splits ids of the entity into existing and unknown ones,
+// list returns the existing ones among the given ids. Unknown ids are an error in strict mode.
+func existing_ids(ctx context.Context, entity string, list func(context.Context, []int32) ([]int32, error), ids []int32, strict bool) ([]int32, error) {
+	if len(ids) == 0 {
+		return []int32{}, nil
+	}
+	existing, err := list(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	var unknown []int32
+	for _, id := range ids {
+		if !slices.Contains(existing, id) && !slices.Contains(unknown, id) {
+			unknown = append(unknown, id)
+		}
+	}
+	if strict && len(unknown) > 0 {
+		return nil, unknownIdsError{entity: entity, ids: unknown}
+	}
+	return existing, nil
+}
+
+type DetailedMovie struct {
+	db.Movie
+	Cast   []CastMember `json:"cast"`
+	Crew   []CrewMember `json:"crew"`
+	Genres []db.Genre   `json:"genres"`
+	// Ratings of the users, the editorial one is the rating of the movie
+	CommunityRating CommunityRating `json:"community_rating"`
+}
+
+func detailed_movie(ctx context.Context, queries *db.Queries, movie db.Movie) (DetailedMovie, error) {
+	cast, err := list_cast(ctx, queries, movie.ID)
+	if err != nil {
+		return DetailedMovie{}, err
+	}
+	crew, err := list_crew(ctx, queries, movie.ID)
+	if err != nil {
+		return DetailedMovie{}, err
+	}
+	genres, err := list_genres(ctx, queries, movie.ID)
+	if err != nil {
+		return DetailedMovie{}, err
+	}
+	rating, err := community_rating(ctx, queries, movie.ID)
+	if err != nil {
+		return DetailedMovie{}, err
+	}
+	return DetailedMovie{Movie: movie, Cast: cast, Crew: crew, Genres: genres, CommunityRating: rating}, nil
+}
+
+// CastEntry is an actor with their part in the movie, a bare actor id is accepted as well
+type CastEntry struct {
+	ActorID      int32    `json:"actor_id"`
+	Characters   []string `json:"characters" example:"Cobb"`
+	BillingOrder *int32   `json:"billing_order" minimum:"1"`
+	Uncredited   bool     `json:"uncredited"`
+	// bare is set for a bare actor id, it must not change the part of an actor already in the cast
+	bare bool
+}
+
+func (self *CastEntry) UnmarshalJSON(data []byte) error {
+	var id int32
+	if err := json.Unmarshal(data, &id); err == nil {
+		*self = CastEntry{ActorID: id, bare: true}
+		return nil
+	}
+	type entry CastEntry
+	return json.Unmarshal(data, (*entry)(self))
+}
+
+func validate_cast(cast []CastEntry) error {
+	for _, entry := range cast {
+		if entry.BillingOrder != nil && *entry.BillingOrder < 1 {
+			return fmt.Errorf("Billing order of actor %d must be a positive number", entry.ActorID)
+		}
+		for _, character := range entry.Characters {
+			if character == "" || utf8.RuneCountInString(character) > 200 {
+				return fmt.Errorf("Character names of actor %d must be between 1 and 200 characters", entry.ActorID)
+			}
+		}
+	}
+	return nil
+}
+
+func cast_actor_ids(cast []CastEntry) []int32 {
+	var out []int32
+	for _, entry := range cast {
+		out = append(out, entry.ActorID)
+	}
+	return out
+}
+
+// existing_cast keeps entries of existing actors, the first entry of a repeated actor wins.
+// Unknown actors are an error in strict mode.
+func existing_cast(ctx context.Context, queries *db.Queries, cast []CastEntry, strict bool) ([]CastEntry, error) {
+	existing, err := existing_ids(ctx, "actor", queries.ListExistingActorIds, cast_actor_ids(cast), strict)
+	if err != nil {
+		return nil, err
+	}
+	out := []CastEntry{}
+	for _, entry := range cast {
+		if slices.Contains(existing, entry.ActorID) && !slices.ContainsFunc(out, func(added CastEntry) bool {
+			return added.ActorID == entry.ActorID
+		}) {
+			out = append(out, entry)
+		}
+	}
+	return out, nil
+}
+
+// create_cast adds actors to the movie cast or replaces details of the ones already in it,
+// bare actor ids leave the details of actors already in the cast as they are
+func create_cast(ctx context.Context, queries *db.Queries, movie_id int32, cast []CastEntry) error {
+	if len(cast) == 0 {
+		return nil
+	}
+	type entry struct {
+		CastEntry
+		Bare bool `json:"bare"`
+	}
+	var rows []entry
+	for _, cast_entry := range cast {
+		rows = append(rows, entry{CastEntry: cast_entry, Bare: cast_entry.bare})
+	}
+	entries, err := json.Marshal(rows)
+	if err != nil {
+		return err
+	}
+	return queries.CreateMovieCast(ctx, db.CreateMovieCastParams{
+		MovieID: movie_id,
+		Entries: entries,
+	})
+}
+
+// CastMember is an actor with their part in the movie
+type CastMember struct {
+	db.Person
+	Characters   []string    `json:"characters"`
+	BillingOrder pgtype.Int4 `json:"billing_order"`
+	Uncredited   bool        `json:"uncredited"`
+}
+
+type CastPayload struct {
+	Actors []CastEntry `json:"actors"`
+}
+
+type FilmographyPayload struct {
+	Movies []int32 `json:"movies"`
+}
+
+func list_cast(ctx context.Context, queries *db.Queries, movie_id int32) ([]CastMember, error) {
+	rows, err := queries.ListMovieCast(ctx, movie_id)
+	if err != nil {
+		return nil, err
+	}
+	out := []CastMember{}
+	for _, row := range rows {
+		member := CastMember{
+			Person:       row.Person,
+			Characters:   row.Characters,
+			BillingOrder: row.BillingOrder,
+			Uncredited:   row.Uncredited,
+		}
+		if member.Characters == nil {
+			member.Characters = []string{}
+		}
+		out = append(out, member)
+	}
+	return out, nil
+}
+
+// change_cast locks the movie and applies change to the validated cast entries
+func (self *Database) change_cast(w http.ResponseWriter, r *http.Request, change func(queries *db.Queries, movie_id int32, cast []CastEntry) error) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strict, err := parse_strict(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var payload CastPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validate_cast(payload.Actors); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var cast []CastMember
+	err = self.with_tx(r.Context(), func(queries *db.Queries) error {
+		_, err := queries.LockMovie(r.Context(), movie_id)
+		if err != nil {
+			return err
+		}
+		entries, err := existing_cast(r.Context(), queries, payload.Actors, strict)
+		if err != nil {
+			return err
+		}
+		err = change(queries, movie_id, entries)
+		if err != nil {
+			return err
+		}
+		cast, err = list_cast(r.Context(), queries, movie_id)
+		return err
+	})
+	if err != nil {
+		cast_error_response(w, err, "Movie not found")
+		return
+	}
+	json_response(w, cast, http.StatusOK)
+}
+
+// change_filmography locks the actor and applies change to the validated movie ids
+func (self *Database) change_filmography(w http.ResponseWriter, r *http.Request, change func(queries *db.Queries, actor_id int32, movie_ids []int32) error) {
+	actor_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strict, err := parse_strict(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var payload FilmographyPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var movies []db.Movie
+	err = self.with_tx(r.Context(), func(queries *db.Queries) error {
+		_, err := queries.LockActor(r.Context(), actor_id)
+		if err != nil {
+			return err
+		}
+		movie_ids, err := existing_ids(r.Context(), "movie", queries.ListExistingMovieIds, payload.Movies, strict)
+		if err != nil {
+			return err
+		}
+		err = change(queries, actor_id, movie_ids)
+		if err != nil {
+			return err
+		}
+		movies, err = queries.ListActorMovies(r.Context(), actor_id)
+		return err
+	})
+	if err != nil {
+		cast_error_response(w, err, "Actor not found")
+		return
+	}
+	if movies == nil {
+		movies = []db.Movie{}
+	}
+	json_response(w, movies, http.StatusOK)
+}
+
+// ListMovieCast
+//
+//	@Summary		List movie cast
+//	@Description	get actors of the movie in billing order, uncredited actors go last
+//	@Tags			cast
+//	@Produce		json
+//	@Param			id	path		int	true	"Movie ID"
+//	@Success		200	{array}		api.CastMember
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/movie_cast/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) ListMovieCast(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := self.Queries.ListExistingMovieIds(r.Context(), []int32{movie_id})
+	if err != nil {
+		log.Printf("ERROR: Failed to find movie: {%s}", err)
+		error_response(w, "Failed to list movie cast", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) == 0 {
+		error_response(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	cast, err := list_cast(r.Context(), &self.Queries, movie_id)
+	if err != nil {
+		log.Printf("ERROR: Failed to list movie cast: {%s}", err)
+		error_response(w, "Failed to list movie cast", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, cast, http.StatusOK)
+}
+
+// AddMovieCast
+//
+//	@Summary		Add actors to movie
+//	@Description	Add actors to the movie cast, details of actors already in the cast are replaced. Unknown actor ids are ignored unless strict mode is requested
+//	@Tags			cast
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Movie ID"
+//	@Param			cast	body		api.CastPayload		true	"Actors to add"
+//	@Param			strict	query		bool				false	"Reject unknown actor ids"	default(false)
+//	@Success		200		{array}		api.CastMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_cast/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) AddMovieCast(w http.ResponseWriter, r *http.Request) {
+	self.change_cast(w, r, func(queries *db.Queries, movie_id int32, cast []CastEntry) error {
+		return create_cast(r.Context(), queries, movie_id, cast)
+	})
+}
+
+// RemoveMovieCast
+//
+//	@Summary		Remove actors from movie
+//	@Description	Remove actors from the movie cast
+//	@Tags			cast
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Movie ID"
+//	@Param			cast	body		api.CastPayload		true	"Actors to remove"
+//	@Param			strict	query		bool				false	"Reject unknown actor ids"	default(false)
+//	@Success		200		{array}		api.CastMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_cast/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) RemoveMovieCast(w http.ResponseWriter, r *http.Request) {
+	self.change_cast(w, r, func(queries *db.Queries, movie_id int32, cast []CastEntry) error {
+		return queries.DeleteMovieActors(r.Context(), db.DeleteMovieActorsParams{
+			MovieID:  movie_id,
+			ActorIds: cast_actor_ids(cast),
+		})
+	})
+}
+
+// ReplaceMovieCast
+//
+//	@Summary		Replace movie cast
+//	@Description	Atomically replace the whole movie cast
+//	@Tags			cast
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Movie ID"
+//	@Param			cast	body		api.CastPayload		true	"New cast"
+//	@Param			strict	query		bool				false	"Reject unknown actor ids"	default(false)
+//	@Success		200		{array}		api.CastMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_cast/{id} [put]
+//	@Security		JwtAuth
+func (self *Database) ReplaceMovieCast(w http.ResponseWriter, r *http.Request) {
+	self.change_cast(w, r, func(queries *db.Queries, movie_id int32, cast []CastEntry) error {
+		err := queries.ClearMovieCast(r.Context(), movie_id)
+		if err != nil {
+			return err
+		}
+		return create_cast(r.Context(), queries, movie_id, cast)
+	})
+}
+
+// AddActorMovies
+//
+//	@Summary		Add movies to actor
+//	@Description	Add the actor to the cast of movies. Unknown movie ids are ignored unless strict mode is requested
+//	@Tags			cast
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Actor ID"
+//	@Param			movies	body		api.FilmographyPayload	true	"Movies to add"
+//	@Param			strict	query		bool					false	"Reject unknown movie ids"	default(false)
+//	@Success		200		{array}		db.Movie
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/actor_movies/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) AddActorMovies(w http.ResponseWriter, r *http.Request) {
+	self.change_filmography(w, r, func(queries *db.Queries, actor_id int32, movie_ids []int32) error {
+		if len(movie_ids) == 0 {
+			return nil
+		}
+		return queries.CreateActorMovies(r.Context(), db.CreateActorMoviesParams{
+			MovieIds: movie_ids,
+			ActorID:  actor_id,
+		})
+	})
+}
+
+// RemoveActorMovies
+//
+//	@Summary		Remove movies from actor
+//	@Description	Remove the actor from the cast of movies
+//	@Tags			cast
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int						true	"Actor ID"
+//	@Param			movies	body		api.FilmographyPayload	true	"Movies to remove"
+//	@Param			strict	query		bool					false	"Reject unknown movie ids"	default(false)
+//	@Success		200		{array}		db.Movie
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/actor_movies/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) RemoveActorMovies(w http.ResponseWriter, r *http.Request) {
+	self.change_filmography(w, r, func(queries *db.Queries, actor_id int32, movie_ids []int32) error {
+		return queries.DeleteActorMovies(r.Context(), db.DeleteActorMoviesParams{
+			ActorID:  actor_id,
+			MovieIds: movie_ids,
+		})
+	})
+}
@@ -0,0 +1,626 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+var collectionVisibilities = []db.CollectionVisibility{
+	db.CollectionVisibilityPrivate,
+	db.CollectionVisibilityUnlisted,
+	db.CollectionVisibilityPublic,
+}
+
+// errOrderMismatch is returned when the new order does not list every item of the collection once
+var errOrderMismatch = errors.New("Order must list every movie of the collection exactly once")
+
+type CollectionPayload struct {
+	Name        pgtype.Text `json:"name" minLength:"1" maxLength:"200" example:"Best of Tarkovsky"`
+	Description pgtype.Text `json:"description" maxLength:"1000"`
+	// Unlisted collections are readable with the share token
+	Visibility *db.CollectionVisibility `json:"visibility" enums:"private,unlisted,public"`
+}
+
+func (c CollectionPayload) Validate() error {
+	if c.Name.Valid && (c.Name.String == "" || utf8.RuneCountInString(c.Name.String) > 200) {
+		return fmt.Errorf("Name length must be between 1 and 200 characters")
+	}
+	if c.Description.Valid && utf8.RuneCountInString(c.Description.String) > 1000 {
+		return fmt.Errorf("Description length must be below 1000 characters")
+	}
+	if c.Visibility != nil && !slices.Contains(collectionVisibilities, *c.Visibility) {
+		return fmt.Errorf("Visibility must be one of private, unlisted or public")
+	}
+	return nil
+}
+
+type CollectionItemPayload struct {
+	MovieID int32       `json:"movie_id" example:"1"`
+	Note    pgtype.Text `json:"note" maxLength:"1000" example:"Watch the director's cut"`
+}
+
+func (c CollectionItemPayload) Validate() error {
+	if c.Note.Valid && utf8.RuneCountInString(c.Note.String) > 1000 {
+		return fmt.Errorf("Note length must be below 1000 characters")
+	}
+	return nil
+}
+
+type CollectionOrderPayload struct {
+	MovieIds []int32 `json:"movie_ids" example:"3,1,2"`
+}
+
+type CollectionItem struct {
+	db.Movie
+	Position int32       `json:"position"`
+	Note     pgtype.Text `json:"note"`
+}
+
+// DetailedCollection is a collection with its movies in order.
+// The share token is shown to the owner only.
+type DetailedCollection struct {
+	db.Collection
+	ShareToken *string          `json:"share_token,omitempty"`
+	Items      []CollectionItem `json:"items"`
+}
+
+func detailed_collection(ctx context.Context, queries *db.Queries, collection db.Collection, owner bool) (DetailedCollection, error) {
+	rows, err := queries.ListCollectionItems(ctx, collection.ID)
+	if err != nil {
+		return DetailedCollection{}, err
+	}
+	out := DetailedCollection{Collection: collection, Items: []CollectionItem{}}
+	if owner {
+		out.ShareToken = &collection.ShareToken
+	}
+	for _, row := range rows {
+		out.Items = append(out.Items, CollectionItem{Movie: row.Movie, Position: row.Position, Note: row.Note})
+	}
+	return out, nil
+}
+
+func parse_item_id(r *http.Request) (int32, error) {
+	id, err := strconv.ParseInt(r.PathValue("movie_id"), 10, 32)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("Could not parse movie_id param")
+	}
+	return int32(id), nil
+}
+
+// ListCollections
+//
+//	@Summary		List own collections
+//	@Description	get collections of the user page by page, newest first
+//	@Tags			collections
+//	@Produce		json
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	api.Page[db.Collection]
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/collections [get]
+//	@Security		JwtAuth
+func (self *Database) ListCollections(w http.ResponseWriter, r *http.Request) {
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var cursor idCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	collections, err := self.Queries.ListUserCollections(r.Context(), db.ListUserCollectionsParams{
+		UserID:    user.ID,
+		BeforeID:  pgtype.Int4{Int32: cursor.ID, Valid: has_cursor},
+		PageLimit: limit + 1,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to list collections: {%s}", err)
+		error_response(w, "Failed to list collections", http.StatusInternalServerError)
+		return
+	}
+	page, err := new_page(collections, limit, func(last db.Collection) any {
+		return idCursor{ID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Failed to list collections", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, page, http.StatusOK)
+}
+
+// AddCollection
+//
+//	@Summary		Create a collection
+//	@Description	Create an empty collection, it is private unless visibility is given
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Param			collection	body		api.CollectionPayload	true	"Collection, name is required"
+//	@Success		201			{object}	api.DetailedCollection
+//	@Failure		400			{object}	api.ServerError
+//	@Failure		401			{object}	api.ServerError
+//	@Failure		500			{object}	api.ServerError
+//	@Router			/collections [post]
+//	@Security		JwtAuth
+func (self *Database) InsertCollection(w http.ResponseWriter, r *http.Request) {
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var payload CollectionPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !payload.Name.Valid {
+		error_response(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Description.String == "" {
+		payload.Description.Valid = false
+	}
+	visibility := db.CollectionVisibilityPrivate
+	if payload.Visibility != nil {
+		visibility = *payload.Visibility
+	}
+
+	share_token, err := newTokenId()
+	if err != nil {
+		log.Printf("ERROR: Failed to generate share token: {%s}", err)
+		error_response(w, "Failed to create collection", http.StatusInternalServerError)
+		return
+	}
+	collection, err := self.Queries.CreateCollection(r.Context(), db.CreateCollectionParams{
+		UserID:      user.ID,
+		Name:        payload.Name.String,
+		Description: payload.Description,
+		Visibility:  visibility,
+		ShareToken:  share_token,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to create collection: {%s}", err)
+		error_response(w, "Failed to create collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, DetailedCollection{Collection: collection, ShareToken: &collection.ShareToken, Items: []CollectionItem{}}, http.StatusCreated)
+}
+
+// GetCollection
+//
+//	@Summary		Get collection
+//	@Description	get the collection with its movies. Public collections are readable without authentication, others only by the owner
+//	@Tags			collections
+//	@Produce		json
+//	@Param			id	path		int	true	"Collection ID"
+//	@Success		200	{object}	api.DetailedCollection
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/collections/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) GetCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	user, ok := requestUser(r)
+
+	out, err := detailed_collection(r.Context(), &self.Queries, collection, ok && user.ID == collection.UserID)
+	if err != nil {
+		log.Printf("ERROR: Failed to list collection items: {%s}", err)
+		error_response(w, "Failed to get collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+// GetSharedCollection
+//
+//	@Summary		Get shared collection
+//	@Description	get the public or unlisted collection by its share token, no authentication is needed
+//	@Tags			collections
+//	@Produce		json
+//	@Param			token	path		string	true	"Share token"
+//	@Success		200		{object}	api.DetailedCollection
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/shared_collections/{token} [get]
+func (self *Database) GetSharedCollection(w http.ResponseWriter, r *http.Request) {
+	collection, err := self.Queries.GetCollectionByShareToken(r.Context(), r.PathValue("token"))
+	if err == pgx.ErrNoRows || collection.Visibility == db.CollectionVisibilityPrivate {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get collection: {%s}", err)
+		error_response(w, "Failed to get collection", http.StatusInternalServerError)
+		return
+	}
+
+	out, err := detailed_collection(r.Context(), &self.Queries, collection, false)
+	if err != nil {
+		log.Printf("ERROR: Failed to list collection items: {%s}", err)
+		error_response(w, "Failed to get collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+// UpdateCollection
+//
+//	@Summary		Update collection
+//	@Description	Change name, description or visibility of your collection, an empty description removes it
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int						true	"Collection ID"
+//	@Param			collection	body		api.CollectionPayload	true	"Changed fields"
+//	@Success		200			{object}	db.Collection
+//	@Failure		400			{object}	api.ServerError
+//	@Failure		401			{object}	api.ServerError
+//	@Failure		403			{object}	api.ServerError
+//	@Failure		404			{object}	api.ServerError
+//	@Failure		500			{object}	api.ServerError
+//	@Router			/collections/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) UpdateCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	var payload CollectionPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var visibility db.NullCollectionVisibility
+	if payload.Visibility != nil {
+		visibility = db.NullCollectionVisibility{CollectionVisibility: *payload.Visibility, Valid: true}
+	}
+	collection, err := self.Queries.UpdateCollection(r.Context(), db.UpdateCollectionParams{
+		ID:          collection.ID,
+		Name:        payload.Name,
+		Description: payload.Description,
+		Visibility:  visibility,
+	})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to update collection: {%s}", err)
+		error_response(w, "Failed to update collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, collection, http.StatusOK)
+}
+
+// ResetCollectionShareToken
+//
+//	@Summary		Reset share token
+//	@Description	Replace the share token of your collection, links with the old token stop working
+//	@Tags			collections
+//	@Produce		json
+//	@Param			id	path		int	true	"Collection ID"
+//	@Success		200	{object}	db.Collection
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/collections/{id}/share_token [post]
+//	@Security		JwtAuth
+func (self *Database) ResetCollectionShareToken(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	share_token, err := newTokenId()
+	if err != nil {
+		log.Printf("ERROR: Failed to generate share token: {%s}", err)
+		error_response(w, "Failed to reset share token", http.StatusInternalServerError)
+		return
+	}
+
+	collection, err = self.Queries.SetCollectionShareToken(r.Context(), db.SetCollectionShareTokenParams{
+		ShareToken: share_token,
+		ID:         collection.ID,
+	})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to reset share token: {%s}", err)
+		error_response(w, "Failed to reset share token", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, collection, http.StatusOK)
+}
+
+// DeleteCollection
+//
+//	@Summary		Delete collection
+//	@Description	Delete your collection with its items
+//	@Tags			collections
+//	@Param			id	path	int	true	"Collection ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/collections/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) DeleteCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	_, err := self.Queries.DeleteCollection(r.Context(), collection.ID)
+	if err == pgx.ErrNoRows {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete collection: {%s}", err)
+		error_response(w, "Failed to delete collection", http.StatusInternalServerError)
+		return
+	}
+}
+
+// AddCollectionItem
+//
+//	@Summary		Add movie to collection
+//	@Description	Add the movie to the end of your collection
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Collection ID"
+//	@Param			item	body		api.CollectionItemPayload	true	"Movie with an optional note"
+//	@Success		201		{object}	db.Collectionitem
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError	"Collection or movie not found"
+//	@Failure		409		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/collections/{id}/items [post]
+//	@Security		JwtAuth
+func (self *Database) AddCollectionItem(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	var payload CollectionItemPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Note.String == "" {
+		payload.Note.Valid = false
+	}
+	if !self.movie_exists(w, r, payload.MovieID) {
+		return
+	}
+
+	// The lock keeps concurrent additions from taking the same position
+	var item db.Collectionitem
+	err := self.with_tx(r.Context(), func(queries *db.Queries) error {
+		if _, err := queries.LockCollection(r.Context(), collection.ID); err != nil {
+			return err
+		}
+		var err error
+		item, err = queries.AddCollectionItem(r.Context(), db.AddCollectionItemParams{
+			CollectionID: collection.ID,
+			MovieID:      payload.MovieID,
+			Note:         payload.Note,
+		})
+		return err
+	})
+	if is_unique_violation(err) {
+		error_response(w, "Movie is already in the collection", http.StatusConflict)
+		return
+	}
+	if err == pgx.ErrNoRows {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to add collection item: {%s}", err)
+		error_response(w, "Failed to add movie to collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, item, http.StatusCreated)
+}
+
+// UpdateCollectionItem
+//
+//	@Summary		Change item note
+//	@Description	Change the note of the movie in your collection, an empty note removes it
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Param			id			path		int							true	"Collection ID"
+//	@Param			movie_id	path		int							true	"Movie ID"
+//	@Param			item		body		api.CollectionItemPayload	true	"New note, movie_id is ignored"
+//	@Success		200			{object}	db.Collectionitem
+//	@Failure		400			{object}	api.ServerError
+//	@Failure		401			{object}	api.ServerError
+//	@Failure		403			{object}	api.ServerError
+//	@Failure		404			{object}	api.ServerError
+//	@Failure		500			{object}	api.ServerError
+//	@Router			/collections/{id}/items/{movie_id} [patch]
+//	@Security		JwtAuth
+func (self *Database) UpdateCollectionItem(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	movie_id, err := parse_item_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var payload CollectionItemPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Note.String == "" {
+		payload.Note.Valid = false
+	}
+
+	item, err := self.Queries.UpdateCollectionItem(r.Context(), db.UpdateCollectionItemParams{
+		Note:         payload.Note,
+		CollectionID: collection.ID,
+		MovieID:      movie_id,
+	})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Movie is not in the collection", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to update collection item: {%s}", err)
+		error_response(w, "Failed to update collection item", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, item, http.StatusOK)
+}
+
+// DeleteCollectionItem
+//
+//	@Summary		Remove movie from collection
+//	@Description	Remove the movie from your collection, the order of other movies is kept
+//	@Tags			collections
+//	@Param			id			path	int	true	"Collection ID"
+//	@Param			movie_id	path	int	true	"Movie ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/collections/{id}/items/{movie_id} [delete]
+//	@Security		JwtAuth
+func (self *Database) DeleteCollectionItem(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	movie_id, err := parse_item_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = self.Queries.DeleteCollectionItem(r.Context(), db.DeleteCollectionItemParams{
+		CollectionID: collection.ID,
+		MovieID:      movie_id,
+	})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Movie is not in the collection", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete collection item: {%s}", err)
+		error_response(w, "Failed to remove movie from collection", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ReorderCollection
+//
+//	@Summary		Reorder collection
+//	@Description	Set the order of movies in your collection, every movie of the collection must be listed once
+//	@Tags			collections
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int							true	"Collection ID"
+//	@Param			order	body		api.CollectionOrderPayload	true	"Movie ids in the new order"
+//	@Success		200		{object}	api.DetailedCollection
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/collections/{id}/order [put]
+//	@Security		JwtAuth
+func (self *Database) ReorderCollection(w http.ResponseWriter, r *http.Request) {
+	collection, _ := requestCollection(r)
+	var payload CollectionOrderPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var out DetailedCollection
+	err := self.with_tx(r.Context(), func(queries *db.Queries) error {
+		if _, err := queries.LockCollection(r.Context(), collection.ID); err != nil {
+			return err
+		}
+		items, err := queries.ListCollectionItems(r.Context(), collection.ID)
+		if err != nil {
+			return err
+		}
+		current := make(map[int32]bool, len(items))
+		for _, item := range items {
+			current[item.Movie.ID] = true
+		}
+		seen := make(map[int32]bool, len(payload.MovieIds))
+		for _, id := range payload.MovieIds {
+			if !current[id] || seen[id] {
+				return errOrderMismatch
+			}
+			seen[id] = true
+		}
+		if len(seen) != len(current) {
+			return errOrderMismatch
+		}
+
+		_, err = queries.ReorderCollectionItems(r.Context(), db.ReorderCollectionItemsParams{
+			MovieIds:     payload.MovieIds,
+			CollectionID: collection.ID,
+		})
+		if err != nil {
+			return err
+		}
+		out, err = detailed_collection(r.Context(), queries, collection, true)
+		return err
+	})
+	if errors.Is(err, errOrderMismatch) {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err == pgx.ErrNoRows {
+		error_response(w, "Collection not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to reorder collection: {%s}", err)
+		error_response(w, "Failed to reorder collection", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, out, http.StatusOK)
+}
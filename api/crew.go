@@ -0,0 +1,280 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+
+	"github.com/dog4ik/philmotecha/db"
+)
+
+// Credit roles of the crew, actors are managed by the cast endpoints
+var crewRoles = []db.CreditRole{
+	db.CreditRoleDirector,
+	db.CreditRoleWriter,
+	db.CreditRoleProducer,
+	db.CreditRoleComposer,
+}
+
+// CrewMember is a person credited in a role other than acting
+type CrewMember struct {
+	db.Person
+	Role db.CreditRole `json:"role" enums:"director,writer,producer,composer"`
+}
+
+// CrewMovie is a movie the person worked on in a role other than acting
+type CrewMovie struct {
+	ActorMovie
+	Role db.CreditRole `json:"role" enums:"director,writer,producer,composer"`
+}
+
+type DetailedPerson struct {
+	DetailedActor
+	Crew []CrewMovie `json:"crew"`
+}
+
+type CrewCredit struct {
+	PersonID int32         `json:"person_id"`
+	Role     db.CreditRole `json:"role" enums:"director,writer,producer,composer"`
+}
+
+type CrewPayload struct {
+	Crew []CrewCredit `json:"crew"`
+}
+
+func (c CrewPayload) Validate() error {
+	for _, credit := range c.Crew {
+		if !slices.Contains(crewRoles, credit.Role) {
+			return fmt.Errorf("Role %q is not a crew role, it must be one of director, writer, producer or composer", credit.Role)
+		}
+	}
+	return nil
+}
+
+func list_crew(ctx context.Context, queries *db.Queries, movie_id int32) ([]CrewMember, error) {
+	rows, err := queries.ListMovieCrew(ctx, movie_id)
+	if err != nil {
+		return nil, err
+	}
+	out := []CrewMember{}
+	for _, row := range rows {
+		out = append(out, CrewMember{Person: row.Person, Role: row.Role})
+	}
+	return out, nil
+}
+
+func list_crew_movies(ctx context.Context, queries *db.Queries, person_id int32) ([]CrewMovie, error) {
+	rows, err := queries.ListPersonCrewMovies(ctx, person_id)
+	if err != nil {
+		return nil, err
+	}
+	out := []CrewMovie{}
+	for _, row := range rows {
+		out = append(out, CrewMovie{ActorMovie: actor_movie(row.Movie), Role: row.Role})
+	}
+	return out, nil
+}
+
+// existing_crew drops credits of unknown people, they are an error in strict mode.
+// Credits are returned as parallel arrays of person ids and roles.
+func existing_crew(ctx context.Context, queries *db.Queries, credits []CrewCredit, strict bool) ([]int32, []string, error) {
+	var person_ids []int32
+	for _, credit := range credits {
+		person_ids = append(person_ids, credit.PersonID)
+	}
+	existing, err := existing_ids(ctx, "actor", queries.ListExistingActorIds, person_ids, strict)
+	if err != nil {
+		return nil, nil, err
+	}
+	person_ids = []int32{}
+	roles := []string{}
+	for _, credit := range credits {
+		if slices.Contains(existing, credit.PersonID) {
+			person_ids = append(person_ids, credit.PersonID)
+			roles = append(roles, string(credit.Role))
+		}
+	}
+	return person_ids, roles, nil
+}
+
+// change_crew locks the movie and applies change to the validated credits
+func (self *Database) change_crew(w http.ResponseWriter, r *http.Request, change func(queries *db.Queries, movie_id int32, person_ids []int32, roles []string) error) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	strict, err := parse_strict(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var payload CrewPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var crew []CrewMember
+	err = self.with_tx(r.Context(), func(queries *db.Queries) error {
+		_, err := queries.LockMovie(r.Context(), movie_id)
+		if err != nil {
+			return err
+		}
+		person_ids, roles, err := existing_crew(r.Context(), queries, payload.Crew, strict)
+		if err != nil {
+			return err
+		}
+		err = change(queries, movie_id, person_ids, roles)
+		if err != nil {
+			return err
+		}
+		crew, err = list_crew(r.Context(), queries, movie_id)
+		return err
+	})
+	if err != nil {
+		cast_error_response(w, err, "Movie not found")
+		return
+	}
+	json_response(w, crew, http.StatusOK)
+}
+
+// ListMovieCrew
+//
+//	@Summary		List movie crew
+//	@Description	get people credited for the movie in roles other than acting
+//	@Tags			crew
+//	@Produce		json
+//	@Param			id	path		int	true	"Movie ID"
+//	@Success		200	{array}		api.CrewMember
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/movie_crew/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) ListMovieCrew(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	existing, err := self.Queries.ListExistingMovieIds(r.Context(), []int32{movie_id})
+	if err != nil {
+		log.Printf("ERROR: Failed to find movie: {%s}", err)
+		error_response(w, "Failed to list movie crew", http.StatusInternalServerError)
+		return
+	}
+	if len(existing) == 0 {
+		error_response(w, "Movie not found", http.StatusNotFound)
+		return
+	}
+
+	crew, err := list_crew(r.Context(), &self.Queries, movie_id)
+	if err != nil {
+		log.Printf("ERROR: Failed to list movie crew: {%s}", err)
+		error_response(w, "Failed to list movie crew", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, crew, http.StatusOK)
+}
+
+// AddMovieCrew
+//
+//	@Summary		Add crew to movie
+//	@Description	Credit people for the movie. Unknown person ids are ignored unless strict mode is requested
+//	@Tags			crew
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Movie ID"
+//	@Param			crew	body		api.CrewPayload	true	"Credits to add"
+//	@Param			strict	query		bool			false	"Reject unknown person ids"	default(false)
+//	@Success		200		{array}		api.CrewMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_crew/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) AddMovieCrew(w http.ResponseWriter, r *http.Request) {
+	self.change_crew(w, r, func(queries *db.Queries, movie_id int32, person_ids []int32, roles []string) error {
+		return queries.CreateMovieCredits(r.Context(), db.CreateMovieCreditsParams{
+			MovieID:   movie_id,
+			PersonIds: person_ids,
+			Roles:     roles,
+		})
+	})
+}
+
+// RemoveMovieCrew
+//
+//	@Summary		Remove crew from movie
+//	@Description	Remove credits of the movie
+//	@Tags			crew
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Movie ID"
+//	@Param			crew	body		api.CrewPayload	true	"Credits to remove"
+//	@Param			strict	query		bool			false	"Reject unknown person ids"	default(false)
+//	@Success		200		{array}		api.CrewMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_crew/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) RemoveMovieCrew(w http.ResponseWriter, r *http.Request) {
+	self.change_crew(w, r, func(queries *db.Queries, movie_id int32, person_ids []int32, roles []string) error {
+		return queries.DeleteMovieCredits(r.Context(), db.DeleteMovieCreditsParams{
+			PersonIds: person_ids,
+			Roles:     roles,
+			MovieID:   movie_id,
+		})
+	})
+}
+
+// ReplaceMovieCrew
+//
+//	@Summary		Replace movie crew
+//	@Description	Atomically replace the whole movie crew, the cast stays as is
+//	@Tags			crew
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int				true	"Movie ID"
+//	@Param			crew	body		api.CrewPayload	true	"New crew"
+//	@Param			strict	query		bool			false	"Reject unknown person ids"	default(false)
+//	@Success		200		{array}		api.CrewMember
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		422		{object}	api.UnknownIdsError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_crew/{id} [put]
+//	@Security		JwtAuth
+func (self *Database) ReplaceMovieCrew(w http.ResponseWriter, r *http.Request) {
+	self.change_crew(w, r, func(queries *db.Queries, movie_id int32, person_ids []int32, roles []string) error {
+		err := queries.ClearMovieCrew(r.Context(), movie_id)
+		if err != nil {
+			return err
+		}
+		return queries.CreateMovieCredits(r.Context(), db.CreateMovieCreditsParams{
+			MovieID:   movie_id,
+			PersonIds: person_ids,
+			Roles:     roles,
+		})
+	})
+}
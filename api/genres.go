@@ -0,0 +1,240 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Postgres error code of unique constraint violations
+const uniqueViolation = "23505"
+
+// GenreCount is a genre with the number of movies in it
+type GenreCount struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	MovieCount int32  `json:"movie_count"`
+}
+
+type GenrePayload struct {
+	Name string `json:"name" minLength:"1" maxLength:"50" example:"Drama"`
+}
+
+func (c GenrePayload) Validate() error {
+	if c.Name == "" || utf8.RuneCountInString(c.Name) > 50 {
+		return fmt.Errorf("Genre name length must be between 1 and 50 characters")
+	}
+	return nil
+}
+
+// genre_counts counts movies of every genre among the movies that pass the listing filters
+func genre_counts(ctx context.Context, queries *db.Queries, params db.ListMoviesParams) ([]GenreCount, error) {
+	rows, err := queries.CountMovieGenres(ctx, db.CountMovieGenresParams{
+		ReleasedAfter:  params.ReleasedAfter,
+		ReleasedBefore: params.ReleasedBefore,
+		MinRating:      params.MinRating,
+		MaxRating:      params.MaxRating,
+		HasDescription: params.HasDescription,
+		TitlePrefix:    params.TitlePrefix,
+		ActorIds:       params.ActorIds,
+		DirectorIds:    params.DirectorIds,
+		GenreIds:       params.GenreIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := []GenreCount{}
+	for _, row := range rows {
+		out = append(out, GenreCount{ID: row.ID, Name: row.Name, MovieCount: row.MovieCount})
+	}
+	return out, nil
+}
+
+func is_unique_violation(err error) bool {
+	var pg_err *pgconn.PgError
+	return errors.As(err, &pg_err) && pg_err.Code == uniqueViolation
+}
+
+func list_genres(ctx context.Context, queries *db.Queries, movie_id int32) ([]db.Genre, error) {
+	genres, err := queries.ListMovieGenres(ctx, movie_id)
+	if genres == nil {
+		genres = []db.Genre{}
+	}
+	return genres, err
+}
+
+// set_movie_genres replaces genres of the movie, unknown genre ids are always an error
+func set_movie_genres(ctx context.Context, queries *db.Queries, movie_id int32, genre_ids []int32) error {
+	_, err := existing_ids(ctx, "genre", queries.ListExistingGenreIds, genre_ids, true)
+	if err != nil {
+		return err
+	}
+
+	err = queries.ClearMovieGenres(ctx, movie_id)
+	if err != nil || len(genre_ids) == 0 {
+		return err
+	}
+	return queries.CreateMovieGenres(ctx, db.CreateMovieGenresParams{
+		MovieID:  movie_id,
+		GenreIds: genre_ids,
+	})
+}
+
+// ListGenres
+//
+//	@Summary		List genres
+//	@Description	get all genres with the number of movies in each of them
+//	@Tags			genres
+//	@Produce		json
+//	@Success		200	{array}		api.GenreCount
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/list_genres [get]
+//	@Security		JwtAuth
+func (self *Database) ListGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := self.Queries.ListGenres(r.Context())
+	if err != nil {
+		log.Printf("ERROR: Failed to list genres {%s}", err)
+		error_response(w, "failed to list genres", http.StatusInternalServerError)
+		return
+	}
+	out := []GenreCount{}
+	for _, genre := range genres {
+		out = append(out, GenreCount{ID: genre.ID, Name: genre.Name, MovieCount: genre.MovieCount})
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+// AddGenre
+//
+//	@Summary		Add a genre
+//	@Description	Add a genre, names are unique regardless of case
+//	@Tags			genres
+//	@Accept			json
+//	@Produce		json
+//	@Param			genre	body		api.GenrePayload	true	"Add genre"
+//	@Success		201		{object}	db.Genre
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		409		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/add_genre [post]
+//	@Security		JwtAuth
+func (self *Database) InsertGenre(w http.ResponseWriter, r *http.Request) {
+	var payload GenrePayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	genre, err := self.Queries.CreateGenre(r.Context(), payload.Name)
+	if is_unique_violation(err) {
+		error_response(w, "Genre with this name already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to create genre {%s}", err)
+		error_response(w, "failed to insert genre", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, genre, http.StatusCreated)
+}
+
+// UpdateGenre
+//
+//	@Summary		Rename a genre
+//	@Description	Update name of the genre
+//	@Tags			genres
+//	@Accept			json
+//	@Produce		json
+//	@Param			genre	body		api.GenrePayload	true	"Update genre"
+//	@Param			id		path		int					true	"Genre ID"
+//	@Success		200		{object}	db.Genre
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		409		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/update_genre/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) UpdateGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var payload GenrePayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	genre, err := self.Queries.UpdateGenre(r.Context(), db.UpdateGenreParams{ID: id, Name: payload.Name})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Genre is not found", http.StatusNotFound)
+		return
+	}
+	if is_unique_violation(err) {
+		error_response(w, "Genre with this name already exists", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to update genre {%s}", err)
+		error_response(w, "failed to update genre", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, genre, http.StatusOK)
+}
+
+// DeleteGenre
+//
+//	@Summary		Delete a genre
+//	@Description	Delete the genre, movies lose it
+//	@Tags			genres
+//	@Param			id	path	int	true	"Genre ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/delete_genre/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) DeleteGenre(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = self.Queries.DeleteGenre(r.Context(), id)
+	if err == pgx.ErrNoRows {
+		error_response(w, "Genre is not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete genre {%s}", err)
+		error_response(w, "failed to delete genre", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,265 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Arbitrary key of the advisory lock that allows a single maintenance run across all servers
+const maintenanceLockKey int64 = 7_092_214_564
+
+const defaultMaintenanceRunsLimit = 20
+const maxMaintenanceRunsLimit = 100
+
+var errMaintenanceRunning = errors.New("maintenance is already running")
+
+const maintenanceTables = "Movie, MovieSearch, Credit, Person, MovieGenre, Genre"
+
+// VACUUM and REINDEX CONCURRENTLY can not run inside a transaction, every statement runs on its own
+var maintenanceStatements = map[db.MaintenanceTask][]string{
+	db.MaintenanceTaskVacuum:  {"VACUUM " + maintenanceTables},
+	db.MaintenanceTaskAnalyze: {"ANALYZE " + maintenanceTables},
+	db.MaintenanceTaskReindex: {
+		"REINDEX TABLE CONCURRENTLY MovieSearch",
+		"REINDEX TABLE CONCURRENTLY Movie",
+		"REINDEX TABLE CONCURRENTLY Credit",
+		"REINDEX TABLE CONCURRENTLY Person",
+		"REINDEX TABLE CONCURRENTLY MovieGenre",
+	},
+	db.MaintenanceTaskAll: {
+		"VACUUM (ANALYZE) " + maintenanceTables,
+		"REINDEX TABLE CONCURRENTLY MovieSearch",
+		"REINDEX TABLE CONCURRENTLY Movie",
+		"REINDEX TABLE CONCURRENTLY Credit",
+		"REINDEX TABLE CONCURRENTLY Person",
+		"REINDEX TABLE CONCURRENTLY MovieGenre",
+	},
+}
+
+func parse_maintenance_task(value string) (db.MaintenanceTask, error) {
+	if value == "" {
+		return db.MaintenanceTaskAll, nil
+	}
+	task := db.MaintenanceTask(value)
+	if _, ok := maintenanceStatements[task]; !ok {
+		return "", fmt.Errorf("task must be one of vacuum, analyze, reindex or all")
+	}
+	return task, nil
+}
+
+// StartMaintenance records a maintenance run and executes it in the background.
+// Returns errMaintenanceRunning when another run holds the lock.
+func (self *Database) StartMaintenance(ctx context.Context, task db.MaintenanceTask, triggered_by pgtype.Int4) (db.Maintenancerun, error) {
+	conn, err := self.Pool.Acquire(ctx)
+	if err != nil {
+		return db.Maintenancerun{}, err
+	}
+
+	var locked bool
+	err = conn.QueryRow(ctx, "SELECT pg_try_advisory_lock($1)", maintenanceLockKey).Scan(&locked)
+	if err != nil {
+		conn.Release()
+		return db.Maintenancerun{}, err
+	}
+	if !locked {
+		conn.Release()
+		return db.Maintenancerun{}, errMaintenanceRunning
+	}
+
+	queries := db.New(conn)
+	// Nothing else can be running while the lock is held
+	interrupted, err := queries.FailInterruptedMaintenanceRuns(ctx)
+	if err != nil {
+		release_maintenance_lock(conn)
+		return db.Maintenancerun{}, err
+	}
+	if interrupted > 0 {
+		log.Printf("WARN: Marked %d interrupted maintenance runs as failed", interrupted)
+	}
+	run, err := queries.CreateMaintenanceRun(ctx, db.CreateMaintenanceRunParams{
+		Task:        task,
+		TriggeredBy: triggered_by,
+	})
+	if err != nil {
+		release_maintenance_lock(conn)
+		return db.Maintenancerun{}, err
+	}
+
+	go run_maintenance(conn, run)
+	return run, nil
+}
+
+func release_maintenance_lock(conn *pgxpool.Conn) {
+	_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", maintenanceLockKey)
+	if err != nil {
+		// A session that still holds the lock must not return to the pool
+		log.Printf("WARN: Failed to release maintenance lock: %s", err)
+		conn.Conn().Close(context.Background())
+	}
+	conn.Release()
+}
+
+func run_maintenance(conn *pgxpool.Conn, run db.Maintenancerun) {
+	defer release_maintenance_lock(conn)
+	ctx := context.Background()
+	started := time.Now()
+
+	params := db.FinishMaintenanceRunParams{ID: run.ID, Status: db.MaintenanceStatusSucceeded}
+	for _, statement := range maintenanceStatements[run.Task] {
+		_, err := conn.Exec(ctx, statement)
+		if err != nil {
+			log.Printf("ERROR: Maintenance run %d failed on %s: %s", run.ID, statement, err)
+			params.Status = db.MaintenanceStatusFailed
+			params.Error = pgtype.Text{String: err.Error(), Valid: true}
+			break
+		}
+	}
+
+	err := db.New(conn).FinishMaintenanceRun(ctx, params)
+	if err != nil {
+		log.Printf("ERROR: Failed to record maintenance run %d result: %s", run.ID, err)
+		return
+	}
+	log.Printf("Maintenance run %d (%s) %s in %s", run.ID, run.Task, params.Status, time.Since(started).Round(time.Millisecond))
+}
+
+// ScheduleMaintenance starts the task every interval until the context is done
+func (self *Database) ScheduleMaintenance(ctx context.Context, interval time.Duration, task db.MaintenanceTask) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, err := self.StartMaintenance(ctx, task, pgtype.Int4{})
+			if err == errMaintenanceRunning {
+				log.Printf("Skipped scheduled maintenance, another run is in progress")
+			} else if err != nil {
+				log.Printf("ERROR: Failed to start scheduled maintenance: %s", err)
+			}
+		}
+	}
+}
+
+// RunMaintenance
+//
+//	@Summary		Run database maintenance
+//	@Description	Start vacuum, analyze or reindex of the movie tables in the background. Only one run can be in progress
+//	@Tags			server
+//	@Produce		json
+//	@Param			task	query		string	false	"Maintenance task"	Enums(vacuum, analyze, reindex, all)	default(all)
+//	@Success		202		{object}	db.Maintenancerun
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		409		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/maintenance [post]
+//	@Security		JwtAuth
+func (self *Database) RunMaintenance(w http.ResponseWriter, r *http.Request) {
+	task, err := parse_maintenance_task(r.URL.Query().Get("task"))
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var triggered_by pgtype.Int4
+	if user, ok := requestUser(r); ok {
+		triggered_by = pgtype.Int4{Int32: user.ID, Valid: true}
+	}
+
+	run, err := self.StartMaintenance(r.Context(), task, triggered_by)
+	if err == errMaintenanceRunning {
+		error_response(w, err.Error(), http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to start maintenance {%s}", err)
+		error_response(w, "failed to start maintenance", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, run, http.StatusAccepted)
+}
+
+// ListMaintenanceRuns
+//
+//	@Summary		List maintenance runs
+//	@Description	get the most recent maintenance runs, newest first
+//	@Tags			server
+//	@Produce		json
+//	@Param			limit	query		int	false	"Number of runs"	minimum(1)	maximum(100)	default(20)
+//	@Success		200		{array}		db.Maintenancerun
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/maintenance [get]
+//	@Security		JwtAuth
+func (self *Database) ListMaintenanceRuns(w http.ResponseWriter, r *http.Request) {
+	limit := defaultMaintenanceRunsLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxMaintenanceRunsLimit {
+			error_response(w, fmt.Sprintf("limit must be a number between 1 and %d", maxMaintenanceRunsLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	runs, err := self.Queries.ListMaintenanceRuns(r.Context(), int32(limit))
+	if err != nil {
+		log.Printf("ERROR: Failed to list maintenance runs {%s}", err)
+		error_response(w, "failed to list maintenance runs", http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []db.Maintenancerun{}
+	}
+	json_response(w, runs, http.StatusOK)
+}
+
+// GetMaintenanceRun
+//
+//	@Summary		Get a maintenance run
+//	@Description	get status of a maintenance run
+//	@Tags			server
+//	@Produce		json
+//	@Param			id	path		int	true	"Maintenance run ID"
+//	@Success		200	{object}	db.Maintenancerun
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/maintenance/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) GetMaintenanceRun(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	run, err := self.Queries.GetMaintenanceRun(r.Context(), id)
+	if err == pgx.ErrNoRows {
+		error_response(w, "maintenance run is not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get maintenance run {%s}", err)
+		error_response(w, "failed to get maintenance run", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, run, http.StatusOK)
+}
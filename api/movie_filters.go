@@ -0,0 +1,107 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const dateLayout = "2006-01-02"
+
+func parse_date_param(r *http.Request, name string) (pgtype.Date, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return pgtype.Date{}, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return pgtype.Date{}, fmt.Errorf("parameter %s must be a date like 2006-01-02", name)
+	}
+	return pgtype.Date{Time: date, Valid: true}, nil
+}
+
+func parse_rating_param(r *http.Request, name string) (pgtype.Numeric, error) {
+	var rating pgtype.Numeric
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return rating, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || number < 0 || number > 10 {
+		return rating, fmt.Errorf("parameter %s must be a number between 0 and 10", name)
+	}
+	err = rating.Scan(value)
+	if err != nil {
+		return rating, fmt.Errorf("parameter %s must be a number between 0 and 10", name)
+	}
+	return rating, nil
+}
+
+// parse_ids reads ids given as repeated or comma separated parameters like actor=1,2&actor=3
+func parse_ids(r *http.Request, name string) ([]int32, error) {
+	var out []int32
+	seen := make(map[int32]bool)
+	for _, value := range r.URL.Query()[name] {
+		for _, part := range strings.Split(value, ",") {
+			id, err := strconv.ParseInt(strings.TrimSpace(part), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s must be a list of %s ids", name, name)
+			}
+			if !seen[int32(id)] {
+				seen[int32(id)] = true
+				out = append(out, int32(id))
+			}
+		}
+	}
+	return out, nil
+}
+
+// parse_movie_filters fills filters of the movie listing from query parameters.
+// Omitted parameters leave the corresponding filter disabled.
+func parse_movie_filters(r *http.Request, params *db.ListMoviesParams) error {
+	var err error
+	params.ReleasedAfter, err = parse_date_param(r, "released_after")
+	if err != nil {
+		return err
+	}
+	params.ReleasedBefore, err = parse_date_param(r, "released_before")
+	if err != nil {
+		return err
+	}
+	params.MinRating, err = parse_rating_param(r, "min_rating")
+	if err != nil {
+		return err
+	}
+	params.MaxRating, err = parse_rating_param(r, "max_rating")
+	if err != nil {
+		return err
+	}
+
+	if value := r.URL.Query().Get("has_description"); value != "" {
+		has_description, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("parameter has_description must be a boolean")
+		}
+		params.HasDescription = pgtype.Bool{Bool: has_description, Valid: true}
+	}
+
+	if value := r.URL.Query().Get("title_prefix"); value != "" {
+		params.TitlePrefix = pgtype.Text{String: value, Valid: true}
+	}
+
+	params.ActorIds, err = parse_ids(r, "actor")
+	if err != nil {
+		return err
+	}
+	params.DirectorIds, err = parse_ids(r, "director")
+	if err != nil {
+		return err
+	}
+	params.GenreIds, err = parse_ids(r, "genre")
+	return err
+}
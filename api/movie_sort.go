@@ -0,0 +1,150 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dog4ik/philmotecha/db"
+)
+
+const maxSortKeys = 3
+
+type movieSortKey struct {
+	Property string
+	Desc     bool
+}
+
+// movieSort is the order of the movie listing
+type movieSort struct {
+	Keys       []movieSortKey
+	NullsFirst bool
+}
+
+// String returns the canonical form of the order that is stored in cursors
+func (self movieSort) String() string {
+	var keys []string
+	for _, key := range self.Keys {
+		if key.Desc {
+			keys = append(keys, "-"+key.Property)
+		} else {
+			keys = append(keys, key.Property)
+		}
+	}
+	nulls := "last"
+	if self.NullsFirst {
+		nulls = "first"
+	}
+	return strings.Join(keys, ",") + ";nulls=" + nulls
+}
+
+func (self movieSort) apply(params *db.ListMoviesParams) {
+	slots := []struct {
+		key  *string
+		desc *bool
+	}{
+		{&params.SortKey1, &params.SortDesc1},
+		{&params.SortKey2, &params.SortDesc2},
+		{&params.SortKey3, &params.SortDesc3},
+	}
+	for i, key := range self.Keys {
+		*slots[i].key = key.Property
+		*slots[i].desc = key.Desc
+	}
+	params.NullsFirst = self.NullsFirst
+}
+
+// list_movies reads a page of the movie listing.
+// A single sort key is served from the sort indexes, several keys go through the generic keyset query.
+func list_movies(ctx context.Context, queries *db.Queries, movie_sort movieSort, params db.ListMoviesParams) ([]db.Movie, error) {
+	if len(movie_sort.Keys) != 1 {
+		return queries.ListMovies(ctx, params)
+	}
+	return queries.ListMoviesByKey(ctx, db.ListMoviesByKeyParams{
+		ReleasedAfter:     params.ReleasedAfter,
+		ReleasedBefore:    params.ReleasedBefore,
+		MinRating:         params.MinRating,
+		MaxRating:         params.MaxRating,
+		HasDescription:    params.HasDescription,
+		TitlePrefix:       params.TitlePrefix,
+		ActorIds:          params.ActorIds,
+		DirectorIds:       params.DirectorIds,
+		GenreIds:          params.GenreIds,
+		NullsFirst:        params.NullsFirst,
+		SortKey:           params.SortKey1,
+		SortDesc:          params.SortDesc1,
+		CursorID:          params.CursorID,
+		CursorRating:      params.CursorRating,
+		PageLimit:         params.PageLimit,
+		CursorReleaseDate: params.CursorReleaseDate,
+		CursorTitle:       params.CursorTitle,
+	})
+}
+
+func validate_sort_property(property string) error {
+	switch property {
+	case "rating", "title", "date":
+		return nil
+	default:
+		return fmt.Errorf("parameter %s is not recognized", property)
+	}
+}
+
+// parse_movie_sort reads the sort parameter like -rating,title where - means descending order.
+// Without it the legacy sort_by and sort_type parameters are used.
+func parse_movie_sort(r *http.Request) (movieSort, error) {
+	var out movieSort
+
+	switch r.URL.Query().Get("nulls") {
+	case "", "last":
+	case "first":
+		out.NullsFirst = true
+	default:
+		return out, fmt.Errorf("parameter nulls must be first or last")
+	}
+
+	value := r.URL.Query().Get("sort")
+	if value == "" {
+		sort_by := r.URL.Query().Get("sort_by")
+		if sort_by == "" {
+			sort_by = "rating"
+		}
+		if err := validate_sort_property(sort_by); err != nil {
+			return out, err
+		}
+		sort_type := r.URL.Query().Get("sort_type")
+		switch sort_type {
+		case "", "desc", "asc":
+		default:
+			return out, fmt.Errorf("parameter %s is not recognized", sort_type)
+		}
+		out.Keys = []movieSortKey{{Property: sort_by, Desc: sort_type != "asc"}}
+		return out, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		var key movieSortKey
+		part = strings.TrimSpace(part)
+		if strings.HasPrefix(part, "-") {
+			key.Desc = true
+			part = part[1:]
+		} else {
+			part = strings.TrimPrefix(part, "+")
+		}
+		if err := validate_sort_property(part); err != nil {
+			return out, err
+		}
+		if seen[part] {
+			return out, fmt.Errorf("sort property %s is repeated", part)
+		}
+		seen[part] = true
+		key.Property = part
+		out.Keys = append(out.Keys, key)
+	}
+	if len(out.Keys) > maxSortKeys {
+		return out, fmt.Errorf("at most %d sort properties are allowed", maxSortKeys)
+	}
+	return out, nil
+}
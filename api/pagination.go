@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const defaultPageLimit = 50
+const maxPageLimit = 500
+
+// Page is a slice of a listing.
+// NextCursor is null on the last page, otherwise it must be passed as the cursor parameter to get the next page.
+type Page[T any] struct {
+	Items      []T     `json:"items"`
+	NextCursor *string `json:"next_cursor"`
+}
+
+// parse_page reads limit and cursor query parameters.
+// The cursor is decoded into the given value when present.
+func parse_page(r *http.Request, cursor any) (int32, bool, error) {
+	limit := defaultPageLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxPageLimit {
+			return 0, false, fmt.Errorf("limit must be a number between 1 and %d", maxPageLimit)
+		}
+		limit = parsed
+	}
+
+	value := r.URL.Query().Get("cursor")
+	if value == "" {
+		return int32(limit), false, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("cursor is malformed")
+	}
+	err = json.Unmarshal(raw, cursor)
+	if err != nil {
+		return 0, false, fmt.Errorf("cursor is malformed")
+	}
+	return int32(limit), true, nil
+}
+
+// new_page trims items that were fetched with limit + 1 and makes the next cursor from the last item
+func new_page[T any](items []T, limit int32, cursor func(last T) any) (Page[T], error) {
+	if items == nil {
+		items = []T{}
+	}
+	if len(items) <= int(limit) {
+		return Page[T]{Items: items}, nil
+	}
+	items = items[:limit]
+	raw, err := json.Marshal(cursor(items[len(items)-1]))
+	if err != nil {
+		return Page[T]{}, err
+	}
+	next := base64.RawURLEncoding.EncodeToString(raw)
+	return Page[T]{Items: items, NextCursor: &next}, nil
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+)
+
+type PoolStats struct {
+	AcquiredConns           int32 `json:"acquired_conns"`
+	IdleConns               int32 `json:"idle_conns"`
+	ConstructingConns       int32 `json:"constructing_conns"`
+	TotalConns              int32 `json:"total_conns"`
+	MaxConns                int32 `json:"max_conns"`
+	AcquireCount            int64 `json:"acquire_count"`
+	AcquireDurationMs       int64 `json:"acquire_duration_ms"`
+	CanceledAcquireCount    int64 `json:"canceled_acquire_count"`
+	EmptyAcquireCount       int64 `json:"empty_acquire_count"`
+	NewConnsCount           int64 `json:"new_conns_count"`
+	MaxLifetimeDestroyCount int64 `json:"max_lifetime_destroy_count"`
+	MaxIdleDestroyCount     int64 `json:"max_idle_destroy_count"`
+}
+
+// PoolStats
+//
+//	@Summary		Database pool statistics
+//	@Description	get current state of the database connection pool
+//	@Tags			server
+//	@Produce		json
+//	@Success		200	{object}	api.PoolStats
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Router			/pool_stats [get]
+//	@Security		JwtAuth
+func (self *Database) PoolStats(w http.ResponseWriter, r *http.Request) {
+	stat := self.Pool.Stat()
+	json_response(w, PoolStats{
+		AcquiredConns:           stat.AcquiredConns(),
+		IdleConns:               stat.IdleConns(),
+		ConstructingConns:       stat.ConstructingConns(),
+		TotalConns:              stat.TotalConns(),
+		MaxConns:                stat.MaxConns(),
+		AcquireCount:            stat.AcquireCount(),
+		AcquireDurationMs:       stat.AcquireDuration().Milliseconds(),
+		CanceledAcquireCount:    stat.CanceledAcquireCount(),
+		EmptyAcquireCount:       stat.EmptyAcquireCount(),
+		NewConnsCount:           stat.NewConnsCount(),
+		MaxLifetimeDestroyCount: stat.MaxLifetimeDestroyCount(),
+		MaxIdleDestroyCount:     stat.MaxIdleDestroyCount(),
+	}, http.StatusOK)
+}
@@ -0,0 +1,323 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"unicode/utf8"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const maxReviewLength = 5000
+
+type ReviewPayload struct {
+	Rating *int32      `json:"rating" minimum:"0" maximum:"10" example:"8"`
+	Body   pgtype.Text `json:"body" maxLength:"5000" example:"Slow, but worth it"`
+}
+
+func (c ReviewPayload) Validate() error {
+	if c.Rating != nil && (*c.Rating < 0 || *c.Rating > 10) {
+		return fmt.Errorf("Rating must be a number between 0 and 10")
+	}
+	if c.Body.Valid && utf8.RuneCountInString(c.Body.String) > maxReviewLength {
+		return fmt.Errorf("Review length must be below %d characters", maxReviewLength)
+	}
+	return nil
+}
+
+// MovieReview is a review with the name of its author
+type MovieReview struct {
+	db.Review
+	Username string `json:"username"`
+}
+
+// CommunityRating aggregates ratings of the users, mean is null when the movie has no reviews
+type CommunityRating struct {
+	Mean  pgtype.Numeric `json:"mean"`
+	Count int32          `json:"count"`
+	// Number of reviews for every rating from 0 to 10
+	Histogram []int32 `json:"histogram"`
+}
+
+type ReviewPage struct {
+	Page[MovieReview]
+	CommunityRating CommunityRating `json:"community_rating"`
+}
+
+func community_rating(ctx context.Context, queries *db.Queries, movie_id int32) (CommunityRating, error) {
+	row, err := queries.GetCommunityRating(ctx, movie_id)
+	if err != nil {
+		return CommunityRating{}, err
+	}
+	return CommunityRating{Mean: row.Mean, Count: row.ReviewCount, Histogram: row.Histogram}, nil
+}
+
+// movie_exists writes the error response when the movie can not be found
+func (self *Database) movie_exists(w http.ResponseWriter, r *http.Request, movie_id int32) bool {
+	existing, err := self.Queries.ListExistingMovieIds(r.Context(), []int32{movie_id})
+	if err != nil {
+		log.Printf("ERROR: Failed to find movie: {%s}", err)
+		error_response(w, "Failed to find movie", http.StatusInternalServerError)
+		return false
+	}
+	if len(existing) == 0 {
+		error_response(w, "Movie not found", http.StatusNotFound)
+		return false
+	}
+	return true
+}
+
+// changeable_review loads the review from the path and checks that the request user wrote it.
+// Admins pass the check when allow_admin is set.
+func (self *Database) changeable_review(w http.ResponseWriter, r *http.Request, allow_admin bool) (db.Review, bool) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return db.Review{}, false
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return db.Review{}, false
+	}
+
+	review, err := self.Queries.GetReview(r.Context(), id)
+	if err == pgx.ErrNoRows {
+		error_response(w, "Review not found", http.StatusNotFound)
+		return db.Review{}, false
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get review: {%s}", err)
+		error_response(w, "Failed to get review", http.StatusInternalServerError)
+		return db.Review{}, false
+	}
+	if review.UserID != user.ID && !(allow_admin && user.Role == db.UserRoleAdmin) {
+		error_response(w, "This review belongs to another user", http.StatusForbidden)
+		return db.Review{}, false
+	}
+	return review, true
+}
+
+// ListMovieReviews
+//
+//	@Summary		List movie reviews
+//	@Description	get reviews of the movie page by page, newest first, with the community rating
+//	@Tags			reviews
+//	@Produce		json
+//	@Param			id		path		int		true	"Movie ID"
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	api.ReviewPage
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_reviews/{id} [get]
+//	@Security		JwtAuth
+func (self *Database) ListMovieReviews(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cursor idCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !self.movie_exists(w, r, movie_id) {
+		return
+	}
+
+	rows, err := self.Queries.ListMovieReviews(r.Context(), db.ListMovieReviewsParams{
+		MovieID:   movie_id,
+		BeforeID:  pgtype.Int4{Int32: cursor.ID, Valid: has_cursor},
+		PageLimit: limit + 1,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to list movie reviews: {%s}", err)
+		error_response(w, "Failed to list movie reviews", http.StatusInternalServerError)
+		return
+	}
+	var reviews []MovieReview
+	for _, row := range rows {
+		reviews = append(reviews, MovieReview{Review: row.Review, Username: row.Username})
+	}
+	page, err := new_page(reviews, limit, func(last MovieReview) any {
+		return idCursor{ID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Failed to list movie reviews", http.StatusInternalServerError)
+		return
+	}
+
+	rating, err := community_rating(r.Context(), &self.Queries, movie_id)
+	if err != nil {
+		log.Printf("ERROR: Failed to get community rating: {%s}", err)
+		error_response(w, "Failed to list movie reviews", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, ReviewPage{Page: page, CommunityRating: rating}, http.StatusOK)
+}
+
+// AddReview
+//
+//	@Summary		Review a movie
+//	@Description	Rate the movie and optionally write a review, every user reviews a movie once
+//	@Tags			reviews
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Movie ID"
+//	@Param			review	body		api.ReviewPayload	true	"Review, rating is required"
+//	@Success		201		{object}	api.MovieReview
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		409		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/movie_reviews/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) InsertReview(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var payload ReviewPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Rating == nil {
+		error_response(w, "Rating is required", http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Body.String == "" {
+		payload.Body.Valid = false
+	}
+	if !self.movie_exists(w, r, movie_id) {
+		return
+	}
+
+	review, err := self.Queries.CreateReview(r.Context(), db.CreateReviewParams{
+		MovieID: movie_id,
+		UserID:  user.ID,
+		Rating:  *payload.Rating,
+		Body:    payload.Body,
+	})
+	if is_unique_violation(err) {
+		error_response(w, "You have already reviewed this movie", http.StatusConflict)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to create review: {%s}", err)
+		error_response(w, "Failed to create review", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, MovieReview{Review: review, Username: user.Username}, http.StatusCreated)
+}
+
+// UpdateReview
+//
+//	@Summary		Edit a review
+//	@Description	Change rating or text of your review, an empty text removes it
+//	@Tags			reviews
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Review ID"
+//	@Param			review	body		api.ReviewPayload	true	"Changed fields"
+//	@Success		200		{object}	api.MovieReview
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/update_review/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) UpdateReview(w http.ResponseWriter, r *http.Request) {
+	var payload ReviewPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	review, ok := self.changeable_review(w, r, false)
+	if !ok {
+		return
+	}
+	// Only the author may edit, so the review belongs to the request user
+	user, _ := requestUser(r)
+
+	var rating pgtype.Int4
+	if payload.Rating != nil {
+		rating = pgtype.Int4{Int32: *payload.Rating, Valid: true}
+	}
+	review, err := self.Queries.UpdateReview(r.Context(), db.UpdateReviewParams{
+		ID:     review.ID,
+		Rating: rating,
+		Body:   payload.Body,
+	})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to update review: {%s}", err)
+		error_response(w, "Failed to update review", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, MovieReview{Review: review, Username: user.Username}, http.StatusOK)
+}
+
+// DeleteReview
+//
+//	@Summary		Delete a review
+//	@Description	Delete your review, admins can delete any review
+//	@Tags			reviews
+//	@Param			id	path	int	true	"Review ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/delete_review/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) DeleteReview(w http.ResponseWriter, r *http.Request) {
+	review, ok := self.changeable_review(w, r, true)
+	if !ok {
+		return
+	}
+
+	_, err := self.Queries.DeleteReview(r.Context(), review.ID)
+	if err == pgx.ErrNoRows {
+		error_response(w, "Review not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete review: {%s}", err)
+		error_response(w, "Failed to delete review", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// How long a negative database lookup is trusted.
+// Tokens revoked by another server instance are rejected after at most this delay.
+const revocationCheckTTL = time.Minute
+
+// revocationCache keeps the results of revocation lookups in memory,
+// so extractUser does not hit RevokedToken table on every request
+type revocationCache struct {
+	mu sync.Mutex
+	// jti -> expiration of the revoked token
+	revoked map[string]time.Time
+	// jti -> moment when the lookup should be repeated
+	checked   map[string]time.Time
+	lastPrune time.Time
+}
+
+var revocations = &revocationCache{
+	revoked: make(map[string]time.Time),
+	checked: make(map[string]time.Time),
+}
+
+func (c *revocationCache) lookup(jti string, now time.Time) (revoked bool, known bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.revoked[jti]; ok {
+		return true, true
+	}
+	if until, ok := c.checked[jti]; ok && now.Before(until) {
+		return false, true
+	}
+	return false, false
+}
+
+func (c *revocationCache) markRevoked(jti string, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.checked, jti)
+	c.revoked[jti] = expires
+	c.prune(time.Now())
+}
+
+func (c *revocationCache) markChecked(jti string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.checked[jti] = now.Add(revocationCheckTTL)
+	c.prune(now)
+}
+
+// prune removes stale entries, must be called with the lock held
+func (c *revocationCache) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < revocationCheckTTL {
+		return
+	}
+	c.lastPrune = now
+	for jti, expires := range c.revoked {
+		if now.After(expires) {
+			delete(c.revoked, jti)
+		}
+	}
+	for jti, until := range c.checked {
+		if now.After(until) {
+			delete(c.checked, jti)
+		}
+	}
+}
+
+func isTokenRevoked(ctx context.Context, queries *db.Queries, jti string) (bool, error) {
+	now := time.Now()
+	if revoked, known := revocations.lookup(jti, now); known {
+		return revoked, nil
+	}
+	revoked, err := queries.IsTokenRevoked(ctx, jti)
+	if err != nil {
+		return false, err
+	}
+	if revoked {
+		// the exact expiration is unknown here, keep the entry for the longest possible token lifetime
+		revocations.markRevoked(jti, now.Add(accessTokenTTL))
+	} else {
+		revocations.markChecked(jti, now)
+	}
+	return revoked, nil
+}
+
+func revokeToken(ctx context.Context, queries *db.Queries, claims *jwtPayload) error {
+	expires := time.UnixMilli(claims.Exp)
+	err := queries.RevokeToken(ctx, db.RevokeTokenParams{
+		Jti:       claims.ID,
+		UserID:    claims.UserId,
+		ExpiresAt: pgtype.Timestamptz{Time: expires, Valid: true},
+	})
+	if err != nil {
+		return err
+	}
+	revocations.markRevoked(claims.ID, expires)
+	return nil
+}
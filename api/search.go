@@ -0,0 +1,395 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/dog4ik/philmotecha/search"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// SearchResult is a movie together with its relevance to the search query
+type SearchResult struct {
+	db.Movie
+	Score     float32    `json:"score"`
+	Highlight *Highlight `json:"highlight,omitempty"`
+}
+
+// Highlight contains the HTML-escaped title and description fragments with matched terms wrapped into markers
+type Highlight struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+const maxMarkerLength = 32
+
+// ts_headline works on plain text, it wraps matches into these control characters
+// and they are swapped for the requested markers once the text is escaped
+const highlightStart = "\x02"
+const highlightStop = "\x03"
+
+// SearchPage is a page of search results.
+// Suggestions are similar titles and actor names, they are listed when an exact search finds nothing.
+type SearchPage struct {
+	Page[SearchResult]
+	Suggestions []string `json:"suggestions"`
+}
+
+const defaultSimilarityThreshold = 0.3
+const suggestionLimit = 5
+
+type searchCursor struct {
+	Mode     string  `json:"m"`
+	Language string  `json:"l,omitempty"`
+	Score    float32 `json:"s"`
+	ID       int32   `json:"id"`
+}
+
+func parse_threshold(r *http.Request) (float32, error) {
+	value := r.URL.Query().Get("threshold")
+	if value == "" {
+		return defaultSimilarityThreshold, nil
+	}
+	threshold, err := strconv.ParseFloat(value, 32)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return 0, fmt.Errorf("parameter threshold must be a number between 0 and 1")
+	}
+	return float32(threshold), nil
+}
+
+func parse_marker(r *http.Request, name string, fallback string) (string, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return fallback, nil
+	}
+	if utf8.RuneCountInString(value) > maxMarkerLength {
+		return "", fmt.Errorf("parameter %s must be at most %d characters", name, maxMarkerLength)
+	}
+	return value, nil
+}
+
+// highlight fills highlights of the results, ts_headline is slow so it runs only for the returned page.
+// Titles and descriptions are HTML-escaped, markers are inserted as they are.
+func (self *Database) highlight(ctx context.Context, results []SearchResult, query string, start string, stop string) error {
+	if query == "" || len(results) == 0 {
+		return nil
+	}
+	ids := make([]int32, len(results))
+	for i, result := range results {
+		ids[i] = result.ID
+	}
+	rows, err := self.Queries.HighlightMovies(ctx, db.HighlightMoviesParams{
+		Markers: fmt.Sprintf(`StartSel="%s", StopSel="%s"`, highlightStart, highlightStop),
+		Query:   query,
+		Ids:     ids,
+	})
+	if err != nil {
+		return err
+	}
+	markers := strings.NewReplacer(highlightStart, start, highlightStop, stop)
+	by_id := make(map[int32]*Highlight)
+	for _, row := range rows {
+		by_id[row.ID] = &Highlight{
+			Title:       markers.Replace(html.EscapeString(row.Title)),
+			Description: markers.Replace(html.EscapeString(row.Description)),
+		}
+	}
+	for i := range results {
+		results[i].Highlight = by_id[results[i].ID]
+	}
+	return nil
+}
+
+func optional_year(year int) pgtype.Int4 {
+	if year == 0 {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: int32(year), Valid: true}
+}
+
+func (self *Database) exact_search(ctx context.Context, params db.SearchMovieParams) ([]SearchResult, error) {
+	rows, err := self.Queries.SearchMovie(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	var out []SearchResult
+	for _, row := range rows {
+		out = append(out, SearchResult{Movie: row.Movie, Score: row.Score})
+	}
+	return out, nil
+}
+
+func (self *Database) fuzzy_search(ctx context.Context, params db.FuzzySearchMovieParams, threshold float32) ([]SearchResult, error) {
+	var out []SearchResult
+	err := self.with_tx(ctx, func(queries *db.Queries) error {
+		err := queries.SetSimilarityThreshold(ctx, threshold)
+		if err != nil {
+			return err
+		}
+		rows, err := queries.FuzzySearchMovie(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			out = append(out, SearchResult{Movie: row.Movie, Score: row.Score})
+		}
+		return nil
+	})
+	return out, err
+}
+
+func (self *Database) search_suggestions(ctx context.Context, query string, threshold float32) ([]string, error) {
+	out := []string{}
+	err := self.with_tx(ctx, func(queries *db.Queries) error {
+		err := queries.SetSimilarityThreshold(ctx, threshold)
+		if err != nil {
+			return err
+		}
+		rows, err := queries.SearchSuggestions(ctx, db.SearchSuggestionsParams{
+			Query:           query,
+			SuggestionLimit: suggestionLimit,
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			out = append(out, row.Suggestion)
+		}
+		return nil
+	})
+	return out, err
+}
+
+// SearchMovie
+//
+//	@Summary		Search movies by title, description and cast
+//	@Description	Query syntax: words are matched as prefixes, "quoted phrases" match words that follow each other, -term excludes movies, a OR b matches either side,
+//	@Description	actor:name keeps movies with a cast member whose name contains name (-actor:name excludes them), director:name does the same for directors,
+//	@Description	year:1999 or year:1990..1999 filters by release year.
+//	@Description	In exact mode title matches rank above description matches, description matches rank above cast matches, cast matches rank above crew matches.
+//	@Description	Words are stemmed according to the movie language, english and russian have stemmers, other languages are matched as is.
+//	@Description	In fuzzy mode titles and actor names are matched by trigram similarity to the query words, so misspelled queries still find movies.
+//	@Description	When an exact search finds nothing, suggestions list similar titles and actor names.
+//	@Tags			movies
+//	@Accept			json
+//	@Produce		json
+//	@Param			q			query		string	true	"search by query"
+//	@Param			mode		query		string	false	"Search mode"	Enums(exact, fuzzy)	default(exact)
+//	@Param			lang		query		string	false	"Search only movies of the language, ISO 639-1 code. All languages are searched by default"	example(ru)
+//	@Param			threshold	query		number	false	"Minimum similarity of fuzzy matches and suggestions"	minimum(0)	maximum(1)	default(0.3)
+//	@Param			genre		query		[]int	false	"Movies in all of the genres"	collectionFormat(multi)
+//	@Param			highlight	query		bool	false	"Return HTML-escaped title and description with matched words wrapped into markers"	default(false)
+//	@Param			start_sel	query		string	false	"Marker placed before a matched word, it is inserted as is"	maxlength(32)	default(<b>)
+//	@Param			stop_sel	query		string	false	"Marker placed after a matched word, it is inserted as is"	maxlength(32)	default(</b>)
+//	@Param			limit		query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor		query		string	false	"next_cursor of the previous page"
+//	@Success		200			{object}	api.SearchPage
+//	@Failure		400			{object}	api.ServerError
+//	@Failure		401			{object}	api.ServerError
+//	@Failure		500			{object}	api.ServerError
+//	@Router			/search [get]
+//	@Security		JwtAuth
+func (self *Database) SearchMovie(w http.ResponseWriter, r *http.Request) {
+	parsed, err := search.Parse(r.URL.Query().Get("q"))
+	if err != nil {
+		error_response(w, fmt.Sprintf("invalid search query: %s", err), http.StatusBadRequest)
+		return
+	}
+	mode := r.URL.Query().Get("mode")
+	switch mode {
+	case "":
+		mode = "exact"
+	case "exact", "fuzzy":
+	default:
+		error_response(w, "parameter mode must be exact or fuzzy", http.StatusBadRequest)
+		return
+	}
+	threshold, err := parse_threshold(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var highlight bool
+	if value := r.URL.Query().Get("highlight"); value != "" {
+		highlight, err = strconv.ParseBool(value)
+		if err != nil {
+			error_response(w, "parameter highlight must be a boolean", http.StatusBadRequest)
+			return
+		}
+	}
+	start_sel, err := parse_marker(r, "start_sel", "<b>")
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	stop_sel, err := parse_marker(r, "stop_sel", "</b>")
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	language := r.URL.Query().Get("lang")
+	if language != "" {
+		if err := validate_language(language); err != nil {
+			error_response(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	genre_ids, err := parse_ids(r, "genre")
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var cursor searchCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if has_cursor && (cursor.Mode != mode || cursor.Language != language) {
+		error_response(w, "cursor belongs to a different search mode or language", http.StatusBadRequest)
+		return
+	}
+	var after_score pgtype.Float4
+	if has_cursor {
+		after_score = pgtype.Float4{Float32: cursor.Score, Valid: true}
+	}
+	if mode == "fuzzy" && parsed.Plain() == "" {
+		error_response(w, "fuzzy search needs words to match", http.StatusBadRequest)
+		return
+	}
+
+	var results []SearchResult
+	if mode == "fuzzy" {
+		results, err = self.fuzzy_search(r.Context(), db.FuzzySearchMovieParams{
+			Query:             parsed.Plain(),
+			AfterScore:        after_score,
+			AfterID:           cursor.ID,
+			Language:          pgtype.Text{String: language, Valid: language != ""},
+			YearFrom:          optional_year(parsed.YearFrom),
+			YearTo:            optional_year(parsed.YearTo),
+			Actors:            parsed.Actors,
+			ExcludedActors:    parsed.ExcludedActors,
+			Directors:         parsed.Directors,
+			ExcludedDirectors: parsed.ExcludedDirectors,
+			GenreIds:          genre_ids,
+			PageLimit:         limit + 1,
+		}, threshold)
+	} else {
+		results, err = self.exact_search(r.Context(), db.SearchMovieParams{
+			Query:             pgtype.Text{String: parsed.TSQuery, Valid: parsed.TSQuery != ""},
+			AfterScore:        after_score,
+			AfterID:           cursor.ID,
+			Language:          pgtype.Text{String: language, Valid: language != ""},
+			YearFrom:          optional_year(parsed.YearFrom),
+			YearTo:            optional_year(parsed.YearTo),
+			Actors:            parsed.Actors,
+			ExcludedActors:    parsed.ExcludedActors,
+			Directors:         parsed.Directors,
+			ExcludedDirectors: parsed.ExcludedDirectors,
+			GenreIds:          genre_ids,
+			PageLimit:         limit + 1,
+		})
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to search movie: %s", err)
+		error_response(w, "Failed to search movie", http.StatusInternalServerError)
+		return
+	}
+
+	page, err := new_page(results, limit, func(last SearchResult) any {
+		return searchCursor{Mode: mode, Language: language, Score: last.Score, ID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Failed to search movie", http.StatusInternalServerError)
+		return
+	}
+
+	if highlight {
+		err = self.highlight(r.Context(), page.Items, parsed.TSQuery, start_sel, stop_sel)
+		if err != nil {
+			log.Printf("ERROR: Failed to highlight search results: %s", err)
+			error_response(w, "Failed to search movie", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	out := SearchPage{Page: page, Suggestions: []string{}}
+	if mode == "exact" && !has_cursor && len(results) == 0 && parsed.Plain() != "" {
+		out.Suggestions, err = self.search_suggestions(r.Context(), parsed.Plain(), threshold)
+		if err != nil {
+			log.Printf("ERROR: Failed to get search suggestions: %s", err)
+			error_response(w, "Failed to search movie", http.StatusInternalServerError)
+			return
+		}
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+const defaultSuggestLimit = 10
+const maxSuggestLimit = 20
+
+// Prefix matches of every kind that are ranked for suggestions,
+// short prefixes match a large part of the catalog and only the first ones in index order are considered
+const suggestCandidateLimit = 200
+
+type Suggestion struct {
+	// movie or actor, actor ids refer to people of any role
+	Type  string `json:"type" enums:"movie,actor"`
+	ID    int32  `json:"id"`
+	Label string `json:"label"`
+}
+
+// Suggest
+//
+//	@Summary		Autocomplete movie titles and actor names
+//	@Description	Movie titles and actor names that start with q, exact matches first, then shorter ones
+//	@Tags			movies
+//	@Produce		json
+//	@Param			q		query	string	true	"Typed prefix"
+//	@Param			limit	query	int		false	"Number of suggestions"	minimum(1)	maximum(20)	default(10)
+//	@Success		200		{array}		api.Suggestion
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/suggest [get]
+//	@Security		JwtAuth
+func (self *Database) Suggest(w http.ResponseWriter, r *http.Request) {
+	prefix := strings.TrimSpace(r.URL.Query().Get("q"))
+	if prefix == "" {
+		error_response(w, "parameter q is required", http.StatusBadRequest)
+		return
+	}
+	limit := defaultSuggestLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > maxSuggestLimit {
+			error_response(w, fmt.Sprintf("limit must be a number between 1 and %d", maxSuggestLimit), http.StatusBadRequest)
+			return
+		}
+		limit = parsed
+	}
+
+	rows, err := self.Queries.Suggest(r.Context(), db.SuggestParams{
+		Prefix:         prefix,
+		CandidateLimit: suggestCandidateLimit,
+		SuggestLimit:   int32(limit),
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to get suggestions: %s", err)
+		error_response(w, "Failed to get suggestions", http.StatusInternalServerError)
+		return
+	}
+	out := []Suggestion{}
+	for _, row := range rows {
+		out = append(out, Suggestion{Type: row.Kind, ID: row.ID, Label: row.Label})
+	}
+	json_response(w, out, http.StatusOK)
+}
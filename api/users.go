@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+)
+
+type UserInfo struct {
+	ID       int32       `json:"id"`
+	Username string      `json:"username"`
+	Role     db.UserRole `json:"role"`
+	Disabled bool        `json:"disabled"`
+}
+
+func userInfo(user db.Appuser) UserInfo {
+	return UserInfo{
+		ID:       user.ID,
+		Username: user.Username,
+		Role:     user.Role,
+		Disabled: user.Disabled,
+	}
+}
+
+type UserRolePayload struct {
+	Role db.UserRole `json:"role" enums:"admin,user"`
+}
+
+// ensure_not_self rejects admin operations that target the admin's own account
+func ensure_not_self(w http.ResponseWriter, r *http.Request, id int32) bool {
+	if user, ok := requestUser(r); ok && user.ID == id {
+		error_response(w, "This operation is not allowed on your own account", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// ListUsers
+//
+//	@Summary		List users
+//	@Description	get all registered users
+//	@Tags			users
+//	@Produce		json
+//	@Success		200	{array}		api.UserInfo
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/list_users [get]
+//	@Security		JwtAuth
+func (self *Database) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := self.Queries.ListUsers(r.Context())
+	if err != nil {
+		log.Printf("ERROR: Failed to list users: {%s}", err)
+		error_response(w, "Failed to list users", http.StatusInternalServerError)
+		return
+	}
+	out := []UserInfo{}
+	for _, user := range users {
+		out = append(out, userInfo(user))
+	}
+	json_response(w, out, http.StatusOK)
+}
+
+// UpdateUserRole
+//
+//	@Summary		Change user role
+//	@Description	Promote or demote user
+//	@Tags			users
+//	@Accept			json
+//	@Produce		json
+//	@Param			role	body		api.UserRolePayload	true	"New role"
+//	@Param			id		path		int					true	"User ID"
+//	@Success		200		{object}	api.UserInfo
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		403		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/update_user_role/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var payload UserRolePayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if payload.Role != db.UserRoleAdmin && payload.Role != db.UserRoleUser {
+		error_response(w, fmt.Sprintf("role %s is not recognized", payload.Role), http.StatusBadRequest)
+		return
+	}
+	if !ensure_not_self(w, r, id) {
+		return
+	}
+
+	user, err := self.Queries.UpdateUserRole(r.Context(), db.UpdateUserRoleParams{
+		ID:   id,
+		Role: payload.Role,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to update user role: {%s}", err)
+		error_response(w, "Failed to update user role", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, userInfo(user), http.StatusOK)
+}
+
+func (self *Database) setUserDisabled(w http.ResponseWriter, r *http.Request, disabled bool) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ensure_not_self(w, r, id) {
+		return
+	}
+
+	user, err := self.Queries.SetUserDisabled(r.Context(), db.SetUserDisabledParams{
+		ID:       id,
+		Disabled: disabled,
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to update user: {%s}", err)
+		error_response(w, "Failed to update user", http.StatusInternalServerError)
+		return
+	}
+
+	if disabled {
+		// sessions of a disabled user must not survive enabling the account again
+		err = self.Queries.RevokeUserTokens(r.Context(), db.RevokeUserTokensParams{
+			ID:               id,
+			TokensValidAfter: RevocationTime(),
+		})
+		if err == nil {
+			err = self.Queries.RevokeUserRefreshTokens(r.Context(), id)
+		}
+		if err != nil {
+			log.Printf("ERROR: Failed to revoke tokens of disabled user: {%s}", err)
+			error_response(w, "Failed to revoke user tokens", http.StatusInternalServerError)
+			return
+		}
+	}
+	json_response(w, userInfo(user), http.StatusOK)
+}
+
+// DisableUser
+//
+//	@Summary		Disable user
+//	@Description	Disable user account and revoke all of its sessions
+//	@Tags			users
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	api.UserInfo
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/disable_user/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) DisableUser(w http.ResponseWriter, r *http.Request) {
+	self.setUserDisabled(w, r, true)
+}
+
+// EnableUser
+//
+//	@Summary		Enable user
+//	@Description	Enable previously disabled user account
+//	@Tags			users
+//	@Produce		json
+//	@Param			id	path		int	true	"User ID"
+//	@Success		200	{object}	api.UserInfo
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/enable_user/{id} [patch]
+//	@Security		JwtAuth
+func (self *Database) EnableUser(w http.ResponseWriter, r *http.Request) {
+	self.setUserDisabled(w, r, false)
+}
+
+// DeleteUser
+//
+//	@Summary		Delete user
+//	@Description	Delete user account
+//	@Tags			users
+//	@Produce		json
+//	@Param			id	path	int	true	"User ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		403	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/delete_user/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !ensure_not_self(w, r, id) {
+		return
+	}
+
+	_, err = self.Queries.DeleteUser(r.Context(), id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			error_response(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("ERROR: Failed to delete user: {%s}", err)
+		error_response(w, "Failed to delete user", http.StatusInternalServerError)
+		return
+	}
+}
@@ -0,0 +1,361 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// ListedMovie is a movie of the listing with its state for the request user
+type ListedMovie struct {
+	db.Movie
+	InWatchlist bool `json:"in_watchlist"`
+	Watched     bool `json:"watched"`
+}
+
+// listed_movies marks movies that are on the watchlist or were watched by the user
+func listed_movies(ctx context.Context, queries *db.Queries, user_id int32, movies []db.Movie) ([]ListedMovie, error) {
+	var ids []int32
+	for _, movie := range movies {
+		ids = append(ids, movie.ID)
+	}
+	flags, err := queries.ListUserMovieFlags(ctx, db.ListUserMovieFlagsParams{UserID: user_id, MovieIds: ids})
+	if err != nil {
+		return nil, err
+	}
+	by_id := make(map[int32]db.ListUserMovieFlagsRow, len(flags))
+	for _, flag := range flags {
+		by_id[flag.ID] = flag
+	}
+
+	var out []ListedMovie
+	for _, movie := range movies {
+		flag := by_id[movie.ID]
+		out = append(out, ListedMovie{Movie: movie, InWatchlist: flag.InWatchlist, Watched: flag.Watched})
+	}
+	return out, nil
+}
+
+type WatchlistMovie struct {
+	db.Movie
+	AddedAt pgtype.Timestamptz `json:"added_at"`
+}
+
+type WatchedMovie struct {
+	db.Movie
+	WatchedOn    pgtype.Date `json:"watched_on"`
+	RewatchCount int32       `json:"rewatch_count"`
+}
+
+type watchlistCursor struct {
+	AddedAt pgtype.Timestamptz `json:"a"`
+	MovieID int32              `json:"id"`
+}
+
+type watchedCursor struct {
+	WatchedOn pgtype.Date `json:"d"`
+	MovieID   int32       `json:"id"`
+}
+
+type WatchedPayload struct {
+	// Defaults to today
+	WatchedOn pgtype.Date `json:"watched_on" example:"2024-03-15"`
+	// Defaults to 0 for a new entry and to one more rewatch for a logged movie
+	RewatchCount *int32 `json:"rewatch_count" minimum:"0" example:"1"`
+}
+
+func (c WatchedPayload) Validate() error {
+	if c.WatchedOn.Valid && c.WatchedOn.Time.After(time.Now()) {
+		return fmt.Errorf("Watch date can not be in the future")
+	}
+	if c.RewatchCount != nil && *c.RewatchCount < 0 {
+		return fmt.Errorf("Rewatch count can not be negative")
+	}
+	return nil
+}
+
+// ListWatchlist
+//
+//	@Summary		List watchlist
+//	@Description	get movies the user wants to see, recently added first
+//	@Tags			watchlist
+//	@Produce		json
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	api.Page[api.WatchlistMovie]
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/watchlist [get]
+//	@Security		JwtAuth
+func (self *Database) ListWatchlist(w http.ResponseWriter, r *http.Request) {
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var cursor watchlistCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := db.ListWatchlistParams{UserID: user.ID, PageLimit: limit + 1}
+	if has_cursor {
+		params.BeforeAddedAt = cursor.AddedAt
+		params.BeforeMovieID = pgtype.Int4{Int32: cursor.MovieID, Valid: true}
+	}
+	rows, err := self.Queries.ListWatchlist(r.Context(), params)
+	if err != nil {
+		log.Printf("ERROR: Failed to list watchlist: {%s}", err)
+		error_response(w, "Failed to list watchlist", http.StatusInternalServerError)
+		return
+	}
+	var movies []WatchlistMovie
+	for _, row := range rows {
+		movies = append(movies, WatchlistMovie{Movie: row.Movie, AddedAt: row.AddedAt})
+	}
+	page, err := new_page(movies, limit, func(last WatchlistMovie) any {
+		return watchlistCursor{AddedAt: last.AddedAt, MovieID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Failed to list watchlist", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, page, http.StatusOK)
+}
+
+// AddToWatchlist
+//
+//	@Summary		Add to watchlist
+//	@Description	Add the movie to the watchlist of the user, adding it again keeps the original date
+//	@Tags			watchlist
+//	@Produce		json
+//	@Param			id	path		int	true	"Movie ID"
+//	@Success		200	{object}	db.Watchlist
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/watchlist/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) AddToWatchlist(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	if !self.movie_exists(w, r, movie_id) {
+		return
+	}
+
+	entry, err := self.Queries.AddToWatchlist(r.Context(), db.AddToWatchlistParams{UserID: user.ID, MovieID: movie_id})
+	if err != nil {
+		log.Printf("ERROR: Failed to add movie to watchlist: {%s}", err)
+		error_response(w, "Failed to add movie to watchlist", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, entry, http.StatusOK)
+}
+
+// RemoveFromWatchlist
+//
+//	@Summary		Remove from watchlist
+//	@Description	Remove the movie from the watchlist of the user
+//	@Tags			watchlist
+//	@Param			id	path	int	true	"Movie ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/watchlist/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) RemoveFromWatchlist(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = self.Queries.RemoveFromWatchlist(r.Context(), db.RemoveFromWatchlistParams{UserID: user.ID, MovieID: movie_id})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Movie is not on the watchlist", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to remove movie from watchlist: {%s}", err)
+		error_response(w, "Failed to remove movie from watchlist", http.StatusInternalServerError)
+		return
+	}
+}
+
+// ListWatched
+//
+//	@Summary		List watched movies
+//	@Description	get movies the user has seen, recently watched first
+//	@Tags			watchlist
+//	@Produce		json
+//	@Param			limit	query		int		false	"Page size"	minimum(1)	maximum(500)	default(50)
+//	@Param			cursor	query		string	false	"next_cursor of the previous page"
+//	@Success		200		{object}	api.Page[api.WatchedMovie]
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/watched [get]
+//	@Security		JwtAuth
+func (self *Database) ListWatched(w http.ResponseWriter, r *http.Request) {
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var cursor watchedCursor
+	limit, has_cursor, err := parse_page(r, &cursor)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := db.ListWatchedParams{UserID: user.ID, PageLimit: limit + 1}
+	if has_cursor {
+		params.BeforeWatchedOn = cursor.WatchedOn
+		params.BeforeMovieID = pgtype.Int4{Int32: cursor.MovieID, Valid: true}
+	}
+	rows, err := self.Queries.ListWatched(r.Context(), params)
+	if err != nil {
+		log.Printf("ERROR: Failed to list watched movies: {%s}", err)
+		error_response(w, "Failed to list watched movies", http.StatusInternalServerError)
+		return
+	}
+	var movies []WatchedMovie
+	for _, row := range rows {
+		movies = append(movies, WatchedMovie{Movie: row.Movie, WatchedOn: row.WatchedOn, RewatchCount: row.RewatchCount})
+	}
+	page, err := new_page(movies, limit, func(last WatchedMovie) any {
+		return watchedCursor{WatchedOn: last.WatchedOn, MovieID: last.ID}
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to encode cursor {%s}", err)
+		error_response(w, "Failed to list watched movies", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, page, http.StatusOK)
+}
+
+// LogWatched
+//
+//	@Summary		Log a watched movie
+//	@Description	Mark the movie as watched. Logging it again keeps the latest watch date and counts a rewatch unless rewatch_count is given
+//	@Tags			watchlist
+//	@Accept			json
+//	@Produce		json
+//	@Param			id		path		int					true	"Movie ID"
+//	@Param			watch	body		api.WatchedPayload	false	"Watch date and rewatch count"
+//	@Success		200		{object}	db.Watched
+//	@Failure		400		{object}	api.ServerError
+//	@Failure		401		{object}	api.ServerError
+//	@Failure		404		{object}	api.ServerError
+//	@Failure		500		{object}	api.ServerError
+//	@Router			/watched/{id} [post]
+//	@Security		JwtAuth
+func (self *Database) LogWatched(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+	var payload WatchedPayload
+	decode_err := json.NewDecoder(r.Body).Decode(&payload)
+	if decode_err != nil && !errors.Is(decode_err, io.EOF) {
+		error_response(w, decode_err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := payload.Validate(); err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !self.movie_exists(w, r, movie_id) {
+		return
+	}
+
+	var rewatch_count pgtype.Int4
+	if payload.RewatchCount != nil {
+		rewatch_count = pgtype.Int4{Int32: *payload.RewatchCount, Valid: true}
+	}
+	entry, err := self.Queries.LogWatched(r.Context(), db.LogWatchedParams{
+		UserID:       user.ID,
+		MovieID:      movie_id,
+		WatchedOn:    payload.WatchedOn,
+		RewatchCount: rewatch_count,
+	})
+	if err != nil {
+		log.Printf("ERROR: Failed to log watched movie: {%s}", err)
+		error_response(w, "Failed to log watched movie", http.StatusInternalServerError)
+		return
+	}
+	json_response(w, entry, http.StatusOK)
+}
+
+// RemoveWatched
+//
+//	@Summary		Remove a watched movie
+//	@Description	Remove the movie from the watched history of the user
+//	@Tags			watchlist
+//	@Param			id	path	int	true	"Movie ID"
+//	@Success		200
+//	@Failure		400	{object}	api.ServerError
+//	@Failure		401	{object}	api.ServerError
+//	@Failure		404	{object}	api.ServerError
+//	@Failure		500	{object}	api.ServerError
+//	@Router			/watched/{id} [delete]
+//	@Security		JwtAuth
+func (self *Database) RemoveWatched(w http.ResponseWriter, r *http.Request) {
+	movie_id, err := parse_path_id(r)
+	if err != nil {
+		error_response(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	user, ok := requestUser(r)
+	if !ok {
+		error_response(w, "User is not authorized", http.StatusUnauthorized)
+		return
+	}
+
+	_, err = self.Queries.RemoveWatched(r.Context(), db.RemoveWatchedParams{UserID: user.ID, MovieID: movie_id})
+	if err == pgx.ErrNoRows {
+		error_response(w, "Movie is not in the watched history", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to remove watched movie: {%s}", err)
+		error_response(w, "Failed to remove watched movie", http.StatusInternalServerError)
+		return
+	}
+}
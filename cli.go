@@ -0,0 +1,374 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dog4ik/philmotecha/api"
+	"github.com/dog4ik/philmotecha/db"
+	"github.com/dog4ik/philmotecha/migrations"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const usage = `Usage: philmotecha <command> [arguments]
+
+Commands:
+  serve                                   start the HTTP server (default)
+  user create [--admin] --username NAME   create a user, password is read from --password or stdin
+  user reset-password --username NAME     set a new password and revoke all sessions of the user
+  migrate [up]                            apply pending migrations
+  migrate down [N]                        revert N most recent migrations (1 by default)
+  migrate status                          show applied and pending migrations
+  seed [--force]                          fill the database with sample actors and movies
+`
+
+func run_cli(args []string) {
+	if len(args) == 0 {
+		serve()
+		return
+	}
+
+	switch args[0] {
+	case "serve":
+		serve()
+	case "user":
+		user_command(args[1:])
+	case "migrate":
+		migrate_command(args[1:])
+	case "seed":
+		seed_command(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command %s\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
+}
+
+// connect opens a single connection for one-shot commands.
+// Unlike the server it does not wait for the database to come up.
+func connect(ctx context.Context) *pgx.Conn {
+	conn, err := pgx.Connect(ctx, lookup_database_url())
+	if err != nil {
+		log.Fatalf("Failed to connect to the database: %s", err)
+	}
+	return conn
+}
+
+func read_password(password string) string {
+	if password != "" {
+		return password
+	}
+	fmt.Fprint(os.Stderr, "Password: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		log.Fatalf("Failed to read password: %s", err)
+	}
+	password = strings.TrimRight(line, "\r\n")
+	if password == "" {
+		log.Fatalf("Password must not be empty")
+	}
+	return password
+}
+
+func user_command(args []string) {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		os.Exit(2)
+	}
+
+	switch args[0] {
+	case "create":
+		user_create(args[1:])
+	case "reset-password":
+		user_reset_password(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown user command %s\n\n%s", args[0], usage)
+		os.Exit(2)
+	}
+}
+
+func user_create(args []string) {
+	flags := flag.NewFlagSet("user create", flag.ExitOnError)
+	username := flags.String("username", "", "name of the new user")
+	password := flags.String("password", "", "password of the new user, read from stdin when omitted")
+	admin := flags.Bool("admin", false, "grant admin rights")
+	flags.Parse(args)
+
+	if *username == "" {
+		log.Fatalf("--username is required")
+	}
+
+	hash, err := api.HashPassword(read_password(*password))
+	if err != nil {
+		log.Fatalf("Failed to hash password: %s", err)
+	}
+
+	role := db.UserRoleUser
+	if *admin {
+		role = db.UserRoleAdmin
+	}
+
+	ctx := context.Background()
+	conn := connect(ctx)
+	defer conn.Close(ctx)
+
+	user, err := db.New(conn).CreateUser(ctx, db.CreateUserParams{
+		Username: *username,
+		Password: hash,
+		Role:     role,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create user: %s", err)
+	}
+	log.Printf("Created %s %s with id %d", user.Role, user.Username, user.ID)
+}
+
+func user_reset_password(args []string) {
+	flags := flag.NewFlagSet("user reset-password", flag.ExitOnError)
+	username := flags.String("username", "", "name of the user")
+	password := flags.String("password", "", "new password, read from stdin when omitted")
+	flags.Parse(args)
+
+	if *username == "" {
+		log.Fatalf("--username is required")
+	}
+
+	hash, err := api.HashPassword(read_password(*password))
+	if err != nil {
+		log.Fatalf("Failed to hash password: %s", err)
+	}
+
+	ctx := context.Background()
+	conn := connect(ctx)
+	defer conn.Close(ctx)
+	queries := db.New(conn)
+
+	id, err := queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{
+		Username:         *username,
+		Password:         hash,
+		TokensValidAfter: api.RevocationTime(),
+	})
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			log.Fatalf("User %s does not exist", *username)
+		}
+		log.Fatalf("Failed to update password: %s", err)
+	}
+	err = queries.RevokeUserRefreshTokens(ctx, id)
+	if err != nil {
+		log.Fatalf("Failed to revoke refresh tokens: %s", err)
+	}
+	log.Printf("Password of %s is changed", *username)
+}
+
+func migrate_command(args []string) {
+	ctx := context.Background()
+	conn := connect(ctx)
+	defer conn.Close(ctx)
+
+	action := "up"
+	if len(args) > 0 {
+		action = args[0]
+	}
+
+	switch action {
+	case "up":
+		applied, err := migrations.Up(ctx, conn)
+		if err != nil {
+			log.Fatalf("Failed to migrate: %s", err)
+		}
+		log.Printf("Applied %d migrations", len(applied))
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				log.Fatalf("Number of steps must be a positive number")
+			}
+			steps = n
+		}
+		reverted, err := migrations.Down(ctx, conn, steps)
+		if err != nil {
+			log.Fatalf("Failed to revert migrations: %s", err)
+		}
+		log.Printf("Reverted %d migrations", len(reverted))
+	case "status":
+		list, err := migrations.List(ctx, conn)
+		if err != nil {
+			log.Fatalf("Failed to read migrations: %s", err)
+		}
+		for _, status := range list {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied at " + status.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Printf("%04d_%s\t%s\n", status.Version, status.Name, state)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown migrate command %s\n\n%s", action, usage)
+		os.Exit(2)
+	}
+}
+
+type seed_movie struct {
+	title       string
+	description string
+	released    time.Time
+	// rating multiplied by 10
+	rating    int64
+	language  string
+	cast      []seed_role
+	directors []string
+}
+
+type seed_role struct {
+	actor     string
+	character string
+}
+
+var seed_actors = []db.CreateActorParams{
+	{Name: "Leonardo DiCaprio", Gender: db.GenderTypeMale, Birth: seed_date(1974, time.November, 11)},
+	{Name: "Joseph Gordon-Levitt", Gender: db.GenderTypeMale, Birth: seed_date(1981, time.February, 17)},
+	{Name: "Elliot Page", Gender: db.GenderTypeMale, Birth: seed_date(1987, time.February, 21)},
+	{Name: "Keanu Reeves", Gender: db.GenderTypeMale, Birth: seed_date(1964, time.September, 2)},
+	{Name: "Carrie-Anne Moss", Gender: db.GenderTypeFemale, Birth: seed_date(1967, time.August, 21)},
+	{Name: "Donatas Banionis", Gender: db.GenderTypeMale, Birth: seed_date(1924, time.April, 28)},
+	{Name: "Natalya Bondarchuk", Gender: db.GenderTypeFemale, Birth: seed_date(1950, time.May, 10)},
+	{Name: "Christopher Nolan", Gender: db.GenderTypeMale, Birth: seed_date(1970, time.July, 30)},
+	{Name: "Lana Wachowski", Gender: db.GenderTypeFemale, Birth: seed_date(1965, time.June, 21)},
+	{Name: "Lilly Wachowski", Gender: db.GenderTypeFemale, Birth: seed_date(1967, time.December, 29)},
+	{Name: "Andrei Tarkovsky", Gender: db.GenderTypeMale, Birth: seed_date(1932, time.April, 4)},
+}
+
+var seed_movies = []seed_movie{
+	{
+		title:       "Inception",
+		description: "A thief who steals corporate secrets through dream-sharing technology is given the task of planting an idea.",
+		released:    time.Date(2010, time.July, 16, 0, 0, 0, 0, time.UTC),
+		rating:      88,
+		language:    "en",
+		cast: []seed_role{
+			{"Leonardo DiCaprio", "Cobb"},
+			{"Joseph Gordon-Levitt", "Arthur"},
+			{"Elliot Page", "Ariadne"},
+		},
+		directors: []string{"Christopher Nolan"},
+	},
+	{
+		title:       "The Matrix",
+		description: "A computer hacker learns about the true nature of his reality.",
+		released:    time.Date(1999, time.March, 31, 0, 0, 0, 0, time.UTC),
+		rating:      87,
+		language:    "en",
+		cast: []seed_role{
+			{"Keanu Reeves", "Neo"},
+			{"Carrie-Anne Moss", "Trinity"},
+		},
+		directors: []string{"Lana Wachowski", "Lilly Wachowski"},
+	},
+	{
+		title:       "Solaris",
+		description: "A psychologist is sent to a station orbiting a distant planet to discover what has caused the crew to go insane.",
+		released:    time.Date(1972, time.March, 20, 0, 0, 0, 0, time.UTC),
+		rating:      80,
+		language:    "en",
+		cast: []seed_role{
+			{"Donatas Banionis", "Kris Kelvin"},
+			{"Natalya Bondarchuk", "Hari"},
+		},
+		directors: []string{"Andrei Tarkovsky"},
+	},
+}
+
+func seed_date(year int, month time.Month, day int) pgtype.Date {
+	return pgtype.Date{Time: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), Valid: true}
+}
+
+func seed_command(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	force := flags.Bool("force", false, "seed even if the database already contains movies")
+	flags.Parse(args)
+
+	ctx := context.Background()
+	conn := connect(ctx)
+	defer conn.Close(ctx)
+
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		log.Fatalf("Failed to start transaction: %s", err)
+	}
+	defer tx.Rollback(ctx)
+	queries := db.New(conn).WithTx(tx)
+
+	count, err := queries.CountMovies(ctx)
+	if err != nil {
+		log.Fatalf("Failed to count movies: %s", err)
+	}
+	if count > 0 && !*force {
+		log.Printf("Database already contains %d movies, use --force to seed anyway", count)
+		return
+	}
+
+	actor_ids := make(map[string]int32)
+	for _, params := range seed_actors {
+		actor, err := queries.CreateActor(ctx, params)
+		if err != nil {
+			log.Fatalf("Failed to create actor %s: %s", params.Name, err)
+		}
+		actor_ids[actor.Name] = actor.ID
+	}
+
+	for _, m := range seed_movies {
+		movie, err := queries.CreateMovie(ctx, db.CreateMovieParams{
+			Title:       m.title,
+			Description: pgtype.Text{String: m.description, Valid: true},
+			ReleaseDate: pgtype.Date{Time: m.released, Valid: true},
+			Rating:      pgtype.Numeric{Int: big.NewInt(m.rating), Exp: -1, Valid: true},
+			Language:    m.language,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create movie %s: %s", m.title, err)
+		}
+		for i, role := range m.cast {
+			err = queries.CreateMovieActor(ctx, db.CreateMovieActorParams{
+				MovieID:      movie.ID,
+				PersonID:     actor_ids[role.actor],
+				Characters:   []string{role.character},
+				BillingOrder: pgtype.Int4{Int32: int32(i + 1), Valid: true},
+			})
+			if err != nil {
+				log.Fatalf("Failed to link %s with %s: %s", role.actor, m.title, err)
+			}
+		}
+		var director_ids []int32
+		var roles []string
+		for _, name := range m.directors {
+			director_ids = append(director_ids, actor_ids[name])
+			roles = append(roles, string(db.CreditRoleDirector))
+		}
+		err = queries.CreateMovieCredits(ctx, db.CreateMovieCreditsParams{
+			MovieID:   movie.ID,
+			PersonIds: director_ids,
+			Roles:     roles,
+		})
+		if err != nil {
+			log.Fatalf("Failed to add directors of %s: %s", m.title, err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		log.Fatalf("Failed to commit seed data: %s", err)
+	}
+	log.Printf("Seeded %d people and %d movies", len(seed_actors), len(seed_movies))
+}
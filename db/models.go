@@ -11,6 +11,94 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type CollectionVisibility string
+
+const (
+	CollectionVisibilityPrivate  CollectionVisibility = "private"
+	CollectionVisibilityUnlisted CollectionVisibility = "unlisted"
+	CollectionVisibilityPublic   CollectionVisibility = "public"
+)
+
+func (e *CollectionVisibility) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CollectionVisibility(s)
+	case string:
+		*e = CollectionVisibility(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CollectionVisibility: %T", src)
+	}
+	return nil
+}
+
+type NullCollectionVisibility struct {
+	CollectionVisibility CollectionVisibility `json:"collection_visibility"`
+	Valid                bool                 `json:"valid"` // Valid is true if CollectionVisibility is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCollectionVisibility) Scan(value interface{}) error {
+	if value == nil {
+		ns.CollectionVisibility, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CollectionVisibility.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCollectionVisibility) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CollectionVisibility), nil
+}
+
+type CreditRole string
+
+const (
+	CreditRoleActor    CreditRole = "actor"
+	CreditRoleDirector CreditRole = "director"
+	CreditRoleWriter   CreditRole = "writer"
+	CreditRoleProducer CreditRole = "producer"
+	CreditRoleComposer CreditRole = "composer"
+)
+
+func (e *CreditRole) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = CreditRole(s)
+	case string:
+		*e = CreditRole(s)
+	default:
+		return fmt.Errorf("unsupported scan type for CreditRole: %T", src)
+	}
+	return nil
+}
+
+type NullCreditRole struct {
+	CreditRole CreditRole `json:"credit_role"`
+	Valid      bool       `json:"valid"` // Valid is true if CreditRole is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullCreditRole) Scan(value interface{}) error {
+	if value == nil {
+		ns.CreditRole, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.CreditRole.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullCreditRole) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.CreditRole), nil
+}
+
 type GenderType string
 
 const (
@@ -54,6 +142,93 @@ func (ns NullGenderType) Value() (driver.Value, error) {
 	return string(ns.GenderType), nil
 }
 
+type MaintenanceStatus string
+
+const (
+	MaintenanceStatusRunning   MaintenanceStatus = "running"
+	MaintenanceStatusSucceeded MaintenanceStatus = "succeeded"
+	MaintenanceStatusFailed    MaintenanceStatus = "failed"
+)
+
+func (e *MaintenanceStatus) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = MaintenanceStatus(s)
+	case string:
+		*e = MaintenanceStatus(s)
+	default:
+		return fmt.Errorf("unsupported scan type for MaintenanceStatus: %T", src)
+	}
+	return nil
+}
+
+type NullMaintenanceStatus struct {
+	MaintenanceStatus MaintenanceStatus `json:"maintenance_status"`
+	Valid             bool              `json:"valid"` // Valid is true if MaintenanceStatus is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullMaintenanceStatus) Scan(value interface{}) error {
+	if value == nil {
+		ns.MaintenanceStatus, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.MaintenanceStatus.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullMaintenanceStatus) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.MaintenanceStatus), nil
+}
+
+type MaintenanceTask string
+
+const (
+	MaintenanceTaskVacuum  MaintenanceTask = "vacuum"
+	MaintenanceTaskAnalyze MaintenanceTask = "analyze"
+	MaintenanceTaskReindex MaintenanceTask = "reindex"
+	MaintenanceTaskAll     MaintenanceTask = "all"
+)
+
+func (e *MaintenanceTask) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = MaintenanceTask(s)
+	case string:
+		*e = MaintenanceTask(s)
+	default:
+		return fmt.Errorf("unsupported scan type for MaintenanceTask: %T", src)
+	}
+	return nil
+}
+
+type NullMaintenanceTask struct {
+	MaintenanceTask MaintenanceTask `json:"maintenance_task"`
+	Valid           bool            `json:"valid"` // Valid is true if MaintenanceTask is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullMaintenanceTask) Scan(value interface{}) error {
+	if value == nil {
+		ns.MaintenanceTask, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.MaintenanceTask.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullMaintenanceTask) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return string(ns.MaintenanceTask), nil
+}
+
 type UserRole string
 
 const (
@@ -96,18 +271,55 @@ func (ns NullUserRole) Value() (driver.Value, error) {
 	return string(ns.UserRole), nil
 }
 
-type Actor struct {
-	ID     int32       `json:"id"`
-	Name   string      `json:"name"`
-	Gender GenderType  `json:"gender"`
-	Birth  pgtype.Date `json:"birth"`
+type Appuser struct {
+	ID               int32              `json:"id"`
+	Username         string             `json:"username"`
+	Password         string             `json:"password"`
+	Role             UserRole           `json:"role"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+	Disabled         bool               `json:"disabled"`
 }
 
-type Appuser struct {
-	ID       int32    `json:"id"`
-	Username string   `json:"username"`
-	Password string   `json:"password"`
-	Role     UserRole `json:"role"`
+type Collection struct {
+	ID          int32                `json:"id"`
+	UserID      int32                `json:"user_id"`
+	Name        string               `json:"name"`
+	Description pgtype.Text          `json:"description"`
+	Visibility  CollectionVisibility `json:"visibility"`
+	ShareToken  string               `json:"share_token"`
+	CreatedAt   pgtype.Timestamptz   `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz   `json:"updated_at"`
+}
+
+type Collectionitem struct {
+	CollectionID int32       `json:"collection_id"`
+	MovieID      int32       `json:"movie_id"`
+	Position     int32       `json:"position"`
+	Note         pgtype.Text `json:"note"`
+}
+
+type Credit struct {
+	MovieID      int32       `json:"movie_id"`
+	PersonID     int32       `json:"person_id"`
+	Role         CreditRole  `json:"role"`
+	Characters   []string    `json:"characters"`
+	BillingOrder pgtype.Int4 `json:"billing_order"`
+	Uncredited   bool        `json:"uncredited"`
+}
+
+type Genre struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+type Maintenancerun struct {
+	ID          int32              `json:"id"`
+	Task        MaintenanceTask    `json:"task"`
+	Status      MaintenanceStatus  `json:"status"`
+	TriggeredBy pgtype.Int4        `json:"triggered_by"`
+	StartedAt   pgtype.Timestamptz `json:"started_at"`
+	FinishedAt  pgtype.Timestamptz `json:"finished_at"`
+	Error       pgtype.Text        `json:"error"`
 }
 
 type Movie struct {
@@ -116,9 +328,67 @@ type Movie struct {
 	Description pgtype.Text    `json:"description"`
 	ReleaseDate pgtype.Date    `json:"release_date"`
 	Rating      pgtype.Numeric `json:"rating"`
+	Language    string         `json:"language"`
 }
 
-type Movieactor struct {
+type Moviegenre struct {
 	MovieID int32 `json:"movie_id"`
-	ActorID int32 `json:"actor_id"`
+	GenreID int32 `json:"genre_id"`
+}
+
+type Moviesearch struct {
+	MovieID     int32       `json:"movie_id"`
+	Title       string      `json:"title"`
+	Description string      `json:"description"`
+	CastNames   string      `json:"cast_names"`
+	Config      interface{} `json:"config"`
+	CrewNames   string      `json:"crew_names"`
+	Document    interface{} `json:"document"`
+}
+
+type Person struct {
+	ID     int32       `json:"id"`
+	Name   string      `json:"name"`
+	Gender GenderType  `json:"gender"`
+	Birth  pgtype.Date `json:"birth"`
+}
+
+type Refreshtoken struct {
+	ID        string             `json:"id"`
+	FamilyID  string             `json:"family_id"`
+	UserID    int32              `json:"user_id"`
+	Used      bool               `json:"used"`
+	Revoked   bool               `json:"revoked"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type Review struct {
+	ID        int32              `json:"id"`
+	MovieID   int32              `json:"movie_id"`
+	UserID    int32              `json:"user_id"`
+	Rating    int32              `json:"rating"`
+	Body      pgtype.Text        `json:"body"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type Revokedtoken struct {
+	Jti       string             `json:"jti"`
+	UserID    int32              `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+	RevokedAt pgtype.Timestamptz `json:"revoked_at"`
+}
+
+type Watched struct {
+	UserID       int32       `json:"user_id"`
+	MovieID      int32       `json:"movie_id"`
+	WatchedOn    pgtype.Date `json:"watched_on"`
+	RewatchCount int32       `json:"rewatch_count"`
+}
+
+type Watchlist struct {
+	UserID  int32              `json:"user_id"`
+	MovieID int32              `json:"movie_id"`
+	AddedAt pgtype.Timestamptz `json:"added_at"`
 }
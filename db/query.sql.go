@@ -11,29 +11,151 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const actorMovies = `-- name: ActorMovies :many
-SELECT m.id, m.title, m.description, m.release_date, m.rating 
-FROM Movie m
-LEFT JOIN MovieActor ma ON m.movie_id = ma.movie_id
-WHERE ma.actor_id = 1
+const addCollectionItem = `-- name: AddCollectionItem :one
+INSERT INTO CollectionItem (collection_id, movie_id, position, note)
+SELECT $1, $2, COALESCE(MAX(position), 0) + 1, $3
+FROM CollectionItem
+WHERE collection_id = $1
+RETURNING collection_id, movie_id, position, note
 `
 
-func (q *Queries) ActorMovies(ctx context.Context) ([]Movie, error) {
-	rows, err := q.db.Query(ctx, actorMovies)
+type AddCollectionItemParams struct {
+	CollectionID int32       `json:"collection_id"`
+	MovieID      int32       `json:"movie_id"`
+	Note         pgtype.Text `json:"note"`
+}
+
+// The movie is placed after the last item
+func (q *Queries) AddCollectionItem(ctx context.Context, arg AddCollectionItemParams) (Collectionitem, error) {
+	row := q.db.QueryRow(ctx, addCollectionItem, arg.CollectionID, arg.MovieID, arg.Note)
+	var i Collectionitem
+	err := row.Scan(
+		&i.CollectionID,
+		&i.MovieID,
+		&i.Position,
+		&i.Note,
+	)
+	return i, err
+}
+
+const addToWatchlist = `-- name: AddToWatchlist :one
+INSERT INTO Watchlist (user_id, movie_id)
+VALUES ($1, $2)
+ON CONFLICT (user_id, movie_id) DO UPDATE
+SET added_at = Watchlist.added_at
+RETURNING user_id, movie_id, added_at
+`
+
+type AddToWatchlistParams struct {
+	UserID  int32 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+// Adding a movie twice keeps the original date
+func (q *Queries) AddToWatchlist(ctx context.Context, arg AddToWatchlistParams) (Watchlist, error) {
+	row := q.db.QueryRow(ctx, addToWatchlist, arg.UserID, arg.MovieID)
+	var i Watchlist
+	err := row.Scan(&i.UserID, &i.MovieID, &i.AddedAt)
+	return i, err
+}
+
+const clearDatabase = `-- name: ClearDatabase :exec
+DROP TABLE AppUser
+`
+
+func (q *Queries) ClearDatabase(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, clearDatabase)
+	return err
+}
+
+const clearMovieCast = `-- name: ClearMovieCast :exec
+DELETE FROM Credit
+WHERE movie_id = $1 AND role = 'actor'
+`
+
+func (q *Queries) ClearMovieCast(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, clearMovieCast, movieID)
+	return err
+}
+
+const clearMovieCrew = `-- name: ClearMovieCrew :exec
+DELETE FROM Credit
+WHERE movie_id = $1 AND role <> 'actor'
+`
+
+func (q *Queries) ClearMovieCrew(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, clearMovieCrew, movieID)
+	return err
+}
+
+const clearMovieGenres = `-- name: ClearMovieGenres :exec
+DELETE FROM MovieGenre
+WHERE movie_id = $1
+`
+
+func (q *Queries) ClearMovieGenres(ctx context.Context, movieID int32) error {
+	_, err := q.db.Exec(ctx, clearMovieGenres, movieID)
+	return err
+}
+
+const countMovieGenres = `-- name: CountMovieGenres :many
+SELECT Genre.id, Genre.name, COUNT(*)::int AS movie_count
+FROM filter_movies(
+    $1::date,
+    $2::date,
+    $3::numeric,
+    $4::numeric,
+    $5::bool,
+    $6::text,
+    $7::int[],
+    $8::int[],
+    $9::int[]
+) AS filtered(id)
+JOIN MovieGenre ON MovieGenre.movie_id = filtered.id
+JOIN Genre ON Genre.id = MovieGenre.genre_id
+GROUP BY Genre.id
+ORDER BY movie_count DESC, Genre.name
+`
+
+type CountMovieGenresParams struct {
+	ReleasedAfter  pgtype.Date    `json:"released_after"`
+	ReleasedBefore pgtype.Date    `json:"released_before"`
+	MinRating      pgtype.Numeric `json:"min_rating"`
+	MaxRating      pgtype.Numeric `json:"max_rating"`
+	HasDescription pgtype.Bool    `json:"has_description"`
+	TitlePrefix    pgtype.Text    `json:"title_prefix"`
+	ActorIds       []int32        `json:"actor_ids"`
+	DirectorIds    []int32        `json:"director_ids"`
+	GenreIds       []int32        `json:"genre_ids"`
+}
+
+type CountMovieGenresRow struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	MovieCount int32  `json:"movie_count"`
+}
+
+// Number of movies in every genre among the movies that pass the ListMovies filters
+func (q *Queries) CountMovieGenres(ctx context.Context, arg CountMovieGenresParams) ([]CountMovieGenresRow, error) {
+	rows, err := q.db.Query(ctx, countMovieGenres,
+		arg.ReleasedAfter,
+		arg.ReleasedBefore,
+		arg.MinRating,
+		arg.MaxRating,
+		arg.HasDescription,
+		arg.TitlePrefix,
+		arg.ActorIds,
+		arg.DirectorIds,
+		arg.GenreIds,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Movie
+	var items []CountMovieGenresRow
 	for rows.Next() {
-		var i Movie
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.ReleaseDate,
-			&i.Rating,
-		); err != nil {
+		var i CountMovieGenresRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.MovieCount); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -44,17 +166,19 @@ func (q *Queries) ActorMovies(ctx context.Context) ([]Movie, error) {
 	return items, nil
 }
 
-const clearDatabase = `-- name: ClearDatabase :exec
-DROP TABLE AppUser
+const countMovies = `-- name: CountMovies :one
+SELECT COUNT(*) FROM Movie
 `
 
-func (q *Queries) ClearDatabase(ctx context.Context) error {
-	_, err := q.db.Exec(ctx, clearDatabase)
-	return err
+func (q *Queries) CountMovies(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countMovies)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
 }
 
 const createActor = `-- name: CreateActor :one
-INSERT INTO Actor (
+INSERT INTO Person (
   name, birth, gender
 ) VALUES (
   $1, $2, $3
@@ -68,9 +192,9 @@ type CreateActorParams struct {
 	Gender GenderType  `json:"gender"`
 }
 
-func (q *Queries) CreateActor(ctx context.Context, arg CreateActorParams) (Actor, error) {
+func (q *Queries) CreateActor(ctx context.Context, arg CreateActorParams) (Person, error) {
 	row := q.db.QueryRow(ctx, createActor, arg.Name, arg.Birth, arg.Gender)
-	var i Actor
+	var i Person
 	err := row.Scan(
 		&i.ID,
 		&i.Name,
@@ -80,13 +204,115 @@ func (q *Queries) CreateActor(ctx context.Context, arg CreateActorParams) (Actor
 	return i, err
 }
 
+const createActorMovies = `-- name: CreateActorMovies :exec
+INSERT INTO Credit (
+  movie_id, person_id, role
+)
+SELECT unnest($1::int[]), $2::int, 'actor'
+ON CONFLICT DO NOTHING
+`
+
+type CreateActorMoviesParams struct {
+	MovieIds []int32 `json:"movie_ids"`
+	ActorID  int32   `json:"actor_id"`
+}
+
+func (q *Queries) CreateActorMovies(ctx context.Context, arg CreateActorMoviesParams) error {
+	_, err := q.db.Exec(ctx, createActorMovies, arg.MovieIds, arg.ActorID)
+	return err
+}
+
+const createCollection = `-- name: CreateCollection :one
+INSERT INTO Collection (
+  user_id, name, description, visibility, share_token
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING id, user_id, name, description, visibility, share_token, created_at, updated_at
+`
+
+type CreateCollectionParams struct {
+	UserID      int32                `json:"user_id"`
+	Name        string               `json:"name"`
+	Description pgtype.Text          `json:"description"`
+	Visibility  CollectionVisibility `json:"visibility"`
+	ShareToken  string               `json:"share_token"`
+}
+
+func (q *Queries) CreateCollection(ctx context.Context, arg CreateCollectionParams) (Collection, error) {
+	row := q.db.QueryRow(ctx, createCollection,
+		arg.UserID,
+		arg.Name,
+		arg.Description,
+		arg.Visibility,
+		arg.ShareToken,
+	)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Visibility,
+		&i.ShareToken,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createGenre = `-- name: CreateGenre :one
+INSERT INTO Genre (
+  name
+) VALUES (
+  $1
+)
+RETURNING id, name
+`
+
+func (q *Queries) CreateGenre(ctx context.Context, name string) (Genre, error) {
+	row := q.db.QueryRow(ctx, createGenre, name)
+	var i Genre
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
+const createMaintenanceRun = `-- name: CreateMaintenanceRun :one
+INSERT INTO MaintenanceRun (
+  task, triggered_by
+) VALUES (
+  $1, $2
+)
+RETURNING id, task, status, triggered_by, started_at, finished_at, error
+`
+
+type CreateMaintenanceRunParams struct {
+	Task        MaintenanceTask `json:"task"`
+	TriggeredBy pgtype.Int4     `json:"triggered_by"`
+}
+
+func (q *Queries) CreateMaintenanceRun(ctx context.Context, arg CreateMaintenanceRunParams) (Maintenancerun, error) {
+	row := q.db.QueryRow(ctx, createMaintenanceRun, arg.Task, arg.TriggeredBy)
+	var i Maintenancerun
+	err := row.Scan(
+		&i.ID,
+		&i.Task,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Error,
+	)
+	return i, err
+}
+
 const createMovie = `-- name: CreateMovie :one
 INSERT INTO Movie (
-  title, description, release_date, rating
+  title, description, release_date, rating, language
 ) VALUES (
-  $1 ,$2, $3, $4 
+  $1 ,$2, $3, $4, $5
 )
-RETURNING id, title, description, release_date, rating
+RETURNING id, title, description, release_date, rating, language
 `
 
 type CreateMovieParams struct {
@@ -94,6 +320,7 @@ type CreateMovieParams struct {
 	Description pgtype.Text    `json:"description"`
 	ReleaseDate pgtype.Date    `json:"release_date"`
 	Rating      pgtype.Numeric `json:"rating"`
+	Language    string         `json:"language"`
 }
 
 func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie, error) {
@@ -102,6 +329,7 @@ func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie
 		arg.Description,
 		arg.ReleaseDate,
 		arg.Rating,
+		arg.Language,
 	)
 	var i Movie
 	err := row.Scan(
@@ -110,35 +338,173 @@ func (q *Queries) CreateMovie(ctx context.Context, arg CreateMovieParams) (Movie
 		&i.Description,
 		&i.ReleaseDate,
 		&i.Rating,
+		&i.Language,
 	)
 	return i, err
 }
 
 const createMovieActor = `-- name: CreateMovieActor :exec
-INSERT INTO MovieActor (
-  movie_id, actor_id
+INSERT INTO Credit (
+  movie_id, person_id, role, characters, billing_order
 ) VALUES (
-  $1, $2
+  $1, $2, 'actor', $3, $4
 )
 `
 
 type CreateMovieActorParams struct {
-	MovieID int32 `json:"movie_id"`
-	ActorID int32 `json:"actor_id"`
+	MovieID      int32       `json:"movie_id"`
+	PersonID     int32       `json:"person_id"`
+	Characters   []string    `json:"characters"`
+	BillingOrder pgtype.Int4 `json:"billing_order"`
 }
 
 func (q *Queries) CreateMovieActor(ctx context.Context, arg CreateMovieActorParams) error {
-	_, err := q.db.Exec(ctx, createMovieActor, arg.MovieID, arg.ActorID)
+	_, err := q.db.Exec(ctx, createMovieActor,
+		arg.MovieID,
+		arg.PersonID,
+		arg.Characters,
+		arg.BillingOrder,
+	)
+	return err
+}
+
+const createMovieCast = `-- name: CreateMovieCast :exec
+INSERT INTO Credit (
+  movie_id, person_id, role, characters, billing_order, uncredited
+)
+SELECT $1::int, entry.actor_id, 'actor', COALESCE(entry.characters, '{}'), entry.billing_order, COALESCE(entry.uncredited, false)
+FROM jsonb_to_recordset($2::jsonb) AS entry(actor_id int, characters text[], billing_order int, uncredited bool, bare bool)
+WHERE NOT (COALESCE(entry.bare, false) AND EXISTS (
+  SELECT 1
+  FROM Credit
+  WHERE Credit.movie_id = $1::int AND Credit.person_id = entry.actor_id AND Credit.role = 'actor'
+))
+ON CONFLICT (movie_id, person_id, role) DO UPDATE SET
+  characters = EXCLUDED.characters,
+  billing_order = EXCLUDED.billing_order,
+  uncredited = EXCLUDED.uncredited
+`
+
+type CreateMovieCastParams struct {
+	MovieID int32  `json:"movie_id"`
+	Entries []byte `json:"entries"`
+}
+
+// entries is a JSON array of objects with actor_id, characters, billing_order, uncredited and bare.
+// Actors that are already in the cast get their details replaced,
+// unless the entry is bare, that is only an actor id was sent.
+func (q *Queries) CreateMovieCast(ctx context.Context, arg CreateMovieCastParams) error {
+	_, err := q.db.Exec(ctx, createMovieCast, arg.MovieID, arg.Entries)
+	return err
+}
+
+const createMovieCredits = `-- name: CreateMovieCredits :exec
+INSERT INTO Credit (
+  movie_id, person_id, role
+)
+SELECT $1::int, credit.person_id, credit.role::credit_role
+FROM unnest($2::int[], $3::text[]) AS credit(person_id, role)
+ON CONFLICT DO NOTHING
+`
+
+type CreateMovieCreditsParams struct {
+	MovieID   int32    `json:"movie_id"`
+	PersonIds []int32  `json:"person_ids"`
+	Roles     []string `json:"roles"`
+}
+
+// person_ids and roles are parallel arrays
+func (q *Queries) CreateMovieCredits(ctx context.Context, arg CreateMovieCreditsParams) error {
+	_, err := q.db.Exec(ctx, createMovieCredits, arg.MovieID, arg.PersonIds, arg.Roles)
+	return err
+}
+
+const createMovieGenres = `-- name: CreateMovieGenres :exec
+INSERT INTO MovieGenre (
+  movie_id, genre_id
+)
+SELECT $1::int, unnest($2::int[])
+ON CONFLICT DO NOTHING
+`
+
+type CreateMovieGenresParams struct {
+	MovieID  int32   `json:"movie_id"`
+	GenreIds []int32 `json:"genre_ids"`
+}
+
+func (q *Queries) CreateMovieGenres(ctx context.Context, arg CreateMovieGenresParams) error {
+	_, err := q.db.Exec(ctx, createMovieGenres, arg.MovieID, arg.GenreIds)
+	return err
+}
+
+const createRefreshToken = `-- name: CreateRefreshToken :exec
+INSERT INTO RefreshToken (
+  id, family_id, user_id, expires_at
+) VALUES (
+  $1, $2, $3, $4
+)
+`
+
+type CreateRefreshTokenParams struct {
+	ID        string             `json:"id"`
+	FamilyID  string             `json:"family_id"`
+	UserID    int32              `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) CreateRefreshToken(ctx context.Context, arg CreateRefreshTokenParams) error {
+	_, err := q.db.Exec(ctx, createRefreshToken,
+		arg.ID,
+		arg.FamilyID,
+		arg.UserID,
+		arg.ExpiresAt,
+	)
 	return err
 }
 
+const createReview = `-- name: CreateReview :one
+INSERT INTO Review (
+  movie_id, user_id, rating, body
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING id, movie_id, user_id, rating, body, created_at, updated_at
+`
+
+type CreateReviewParams struct {
+	MovieID int32       `json:"movie_id"`
+	UserID  int32       `json:"user_id"`
+	Rating  int32       `json:"rating"`
+	Body    pgtype.Text `json:"body"`
+}
+
+func (q *Queries) CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, createReview,
+		arg.MovieID,
+		arg.UserID,
+		arg.Rating,
+		arg.Body,
+	)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO AppUser (
   username, password, role
 ) VALUES (
   $1 ,$2, $3
 )
-RETURNING id, username, password, role
+RETURNING id, username, password, role, tokens_valid_after, disabled
 `
 
 type CreateUserParams struct {
@@ -155,12 +521,14 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (Appuser
 		&i.Username,
 		&i.Password,
 		&i.Role,
+		&i.TokensValidAfter,
+		&i.Disabled,
 	)
 	return i, err
 }
 
 const deleteActor = `-- name: DeleteActor :one
-DELETE FROM Actor
+DELETE FROM Person
 WHERE id = $1
 RETURNING id
 `
@@ -171,6 +539,73 @@ func (q *Queries) DeleteActor(ctx context.Context, id int32) (int32, error) {
 	return id, err
 }
 
+const deleteActorMovies = `-- name: DeleteActorMovies :exec
+DELETE FROM Credit
+WHERE person_id = $1 AND role = 'actor' AND movie_id = ANY($2::int[])
+`
+
+type DeleteActorMoviesParams struct {
+	ActorID  int32   `json:"actor_id"`
+	MovieIds []int32 `json:"movie_ids"`
+}
+
+func (q *Queries) DeleteActorMovies(ctx context.Context, arg DeleteActorMoviesParams) error {
+	_, err := q.db.Exec(ctx, deleteActorMovies, arg.ActorID, arg.MovieIds)
+	return err
+}
+
+const deleteCollection = `-- name: DeleteCollection :one
+DELETE FROM Collection
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteCollection(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, deleteCollection, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const deleteCollectionItem = `-- name: DeleteCollectionItem :one
+DELETE FROM CollectionItem
+WHERE collection_id = $1 AND movie_id = $2
+RETURNING movie_id
+`
+
+type DeleteCollectionItemParams struct {
+	CollectionID int32 `json:"collection_id"`
+	MovieID      int32 `json:"movie_id"`
+}
+
+func (q *Queries) DeleteCollectionItem(ctx context.Context, arg DeleteCollectionItemParams) (int32, error) {
+	row := q.db.QueryRow(ctx, deleteCollectionItem, arg.CollectionID, arg.MovieID)
+	var movie_id int32
+	err := row.Scan(&movie_id)
+	return movie_id, err
+}
+
+const deleteExpiredRevokedTokens = `-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM RevokedToken
+WHERE expires_at < now()
+`
+
+func (q *Queries) DeleteExpiredRevokedTokens(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, deleteExpiredRevokedTokens)
+	return err
+}
+
+const deleteGenre = `-- name: DeleteGenre :one
+DELETE FROM Genre
+WHERE id = $1
+RETURNING id
+`
+
+func (q *Queries) DeleteGenre(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, deleteGenre, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
 const deleteMovie = `-- name: DeleteMovie :one
 DELETE FROM Movie
 WHERE id = $1
@@ -183,99 +618,1648 @@ func (q *Queries) DeleteMovie(ctx context.Context, id int32) (int32, error) {
 	return id, err
 }
 
-const getActor = `-- name: GetActor :one
-SELECT id, name, gender, birth FROM Actor
-WHERE id = $1 LIMIT 1
+const deleteMovieActors = `-- name: DeleteMovieActors :exec
+DELETE FROM Credit
+WHERE movie_id = $1 AND role = 'actor' AND person_id = ANY($2::int[])
 `
 
-func (q *Queries) GetActor(ctx context.Context, id int32) (Actor, error) {
-	row := q.db.QueryRow(ctx, getActor, id)
-	var i Actor
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Gender,
-		&i.Birth,
-	)
-	return i, err
+type DeleteMovieActorsParams struct {
+	MovieID  int32   `json:"movie_id"`
+	ActorIds []int32 `json:"actor_ids"`
 }
 
-const getUserById = `-- name: GetUserById :one
-SELECT id, username, password, role FROM AppUser 
-WHERE id = $1
+func (q *Queries) DeleteMovieActors(ctx context.Context, arg DeleteMovieActorsParams) error {
+	_, err := q.db.Exec(ctx, deleteMovieActors, arg.MovieID, arg.ActorIds)
+	return err
+}
+
+const deleteMovieCredits = `-- name: DeleteMovieCredits :exec
+DELETE FROM Credit
+USING unnest($1::int[], $2::text[]) AS credit(person_id, role)
+WHERE Credit.movie_id = $3
+  AND Credit.person_id = credit.person_id
+  AND Credit.role = credit.role::credit_role
 `
 
-func (q *Queries) GetUserById(ctx context.Context, id int32) (Appuser, error) {
-	row := q.db.QueryRow(ctx, getUserById, id)
-	var i Appuser
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Password,
-		&i.Role,
-	)
-	return i, err
+type DeleteMovieCreditsParams struct {
+	PersonIds []int32  `json:"person_ids"`
+	Roles     []string `json:"roles"`
+	MovieID   int32    `json:"movie_id"`
 }
 
-const getUserByUsername = `-- name: GetUserByUsername :one
-SELECT id, username, password, role FROM AppUser 
-WHERE username = $1
+// person_ids and roles are parallel arrays
+func (q *Queries) DeleteMovieCredits(ctx context.Context, arg DeleteMovieCreditsParams) error {
+	_, err := q.db.Exec(ctx, deleteMovieCredits, arg.PersonIds, arg.Roles, arg.MovieID)
+	return err
+}
+
+const deleteReview = `-- name: DeleteReview :one
+DELETE FROM Review
+WHERE id = $1
+RETURNING id
 `
 
-func (q *Queries) GetUserByUsername(ctx context.Context, username string) (Appuser, error) {
-	row := q.db.QueryRow(ctx, getUserByUsername, username)
-	var i Appuser
-	err := row.Scan(
-		&i.ID,
-		&i.Username,
-		&i.Password,
-		&i.Role,
-	)
-	return i, err
+func (q *Queries) DeleteReview(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, deleteReview, id)
+	err := row.Scan(&id)
+	return id, err
 }
 
-const listActors = `-- name: ListActors :many
-SELECT 
-    actor.id, actor.name, actor.gender, actor.birth,
-    JSON_AGG(json_build_object(
-        'ID', Movie.id,
-        'title', Movie.title,
-        'plot', Movie.description,
-        'release_date', Movie.release_date
-    )) AS movies
-FROM 
-    Actor
-LEFT JOIN 
-    MovieActor ON Actor.id = MovieActor.actor_id
-LEFT JOIN 
-    Movie ON MovieActor.movie_id = Movie.id
-GROUP BY 
-    Actor.id
+const deleteUser = `-- name: DeleteUser :one
+DELETE FROM AppUser
+WHERE id = $1
+RETURNING id
 `
 
-type ListActorsRow struct {
-	ID     int32       `json:"id"`
-	Name   string      `json:"name"`
-	Gender GenderType  `json:"gender"`
-	Birth  pgtype.Date `json:"birth"`
-	Movies []byte      `json:"movies"`
+func (q *Queries) DeleteUser(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, deleteUser, id)
+	err := row.Scan(&id)
+	return id, err
 }
 
-func (q *Queries) ListActors(ctx context.Context) ([]ListActorsRow, error) {
-	rows, err := q.db.Query(ctx, listActors)
-	if err != nil {
+const failInterruptedMaintenanceRuns = `-- name: FailInterruptedMaintenanceRuns :execrows
+UPDATE MaintenanceRun
+  SET status = 'failed',
+  error = 'interrupted',
+  finished_at = now()
+WHERE status = 'running'
+`
+
+// Runs that were still running when their server stopped
+func (q *Queries) FailInterruptedMaintenanceRuns(ctx context.Context) (int64, error) {
+	result, err := q.db.Exec(ctx, failInterruptedMaintenanceRuns)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const finishMaintenanceRun = `-- name: FinishMaintenanceRun :exec
+UPDATE MaintenanceRun
+  SET status = $2,
+  error = $3,
+  finished_at = now()
+WHERE id = $1
+`
+
+type FinishMaintenanceRunParams struct {
+	ID     int32             `json:"id"`
+	Status MaintenanceStatus `json:"status"`
+	Error  pgtype.Text       `json:"error"`
+}
+
+func (q *Queries) FinishMaintenanceRun(ctx context.Context, arg FinishMaintenanceRunParams) error {
+	_, err := q.db.Exec(ctx, finishMaintenanceRun, arg.ID, arg.Status, arg.Error)
+	return err
+}
+
+const fuzzySearchMovie = `-- name: FuzzySearchMovie :many
+WITH matches AS (
+  SELECT Movie.id, word_similarity($1::text, Movie.title) AS score
+  FROM Movie
+  WHERE $1::text <% Movie.title
+  UNION ALL
+  SELECT Credit.movie_id, word_similarity($1::text, Person.name)
+  FROM Person
+  JOIN Credit ON Credit.person_id = Person.id
+  WHERE $1::text <% Person.name
+), scored AS (
+  SELECT id, MAX(score)::real AS score
+  FROM matches
+  GROUP BY id
+)
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, scored.score
+FROM scored
+JOIN Movie ON Movie.id = scored.id
+WHERE ($2::real IS NULL
+    OR scored.score < $2::real
+    OR (scored.score = $2::real AND Movie.id > $3::int))
+  AND ($4::text IS NULL OR Movie.language = $4::text)
+  AND ($5::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) >= $5::int)
+  AND ($6::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) <= $6::int)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($7::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest($8::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($9::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest($10::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($11::int[]) AS wanted(genre_id)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM MovieGenre
+      WHERE MovieGenre.movie_id = Movie.id AND MovieGenre.genre_id = wanted.genre_id
+    )
+  )
+ORDER BY scored.score DESC, Movie.id
+LIMIT $12
+`
+
+type FuzzySearchMovieParams struct {
+	Query             string        `json:"query"`
+	AfterScore        pgtype.Float4 `json:"after_score"`
+	AfterID           int32         `json:"after_id"`
+	Language          pgtype.Text   `json:"language"`
+	YearFrom          pgtype.Int4   `json:"year_from"`
+	YearTo            pgtype.Int4   `json:"year_to"`
+	Actors            []string      `json:"actors"`
+	ExcludedActors    []string      `json:"excluded_actors"`
+	Directors         []string      `json:"directors"`
+	ExcludedDirectors []string      `json:"excluded_directors"`
+	GenreIds          []int32       `json:"genre_ids"`
+	PageLimit         int32         `json:"page_limit"`
+}
+
+type FuzzySearchMovieRow struct {
+	Movie Movie   `json:"movie"`
+	Score float32 `json:"score"`
+}
+
+// Movies whose title or the name of a credited person is similar to the query.
+// Filters are the same as in SearchMovie.
+// Run SetSimilarityThreshold in the same transaction to change the threshold.
+func (q *Queries) FuzzySearchMovie(ctx context.Context, arg FuzzySearchMovieParams) ([]FuzzySearchMovieRow, error) {
+	rows, err := q.db.Query(ctx, fuzzySearchMovie,
+		arg.Query,
+		arg.AfterScore,
+		arg.AfterID,
+		arg.Language,
+		arg.YearFrom,
+		arg.YearTo,
+		arg.Actors,
+		arg.ExcludedActors,
+		arg.Directors,
+		arg.ExcludedDirectors,
+		arg.GenreIds,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []FuzzySearchMovieRow
+	for rows.Next() {
+		var i FuzzySearchMovieRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.Score,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getActor = `-- name: GetActor :one
+SELECT id, name, gender, birth FROM Person
+WHERE id = $1 LIMIT 1
+`
+
+func (q *Queries) GetActor(ctx context.Context, id int32) (Person, error) {
+	row := q.db.QueryRow(ctx, getActor, id)
+	var i Person
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Gender,
+		&i.Birth,
+	)
+	return i, err
+}
+
+const getCollection = `-- name: GetCollection :one
+SELECT id, user_id, name, description, visibility, share_token, created_at, updated_at FROM Collection
+WHERE id = $1
+`
+
+func (q *Queries) GetCollection(ctx context.Context, id int32) (Collection, error) {
+	row := q.db.QueryRow(ctx, getCollection, id)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Visibility,
+		&i.ShareToken,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCollectionByShareToken = `-- name: GetCollectionByShareToken :one
+SELECT id, user_id, name, description, visibility, share_token, created_at, updated_at FROM Collection
+WHERE share_token = $1
+`
+
+func (q *Queries) GetCollectionByShareToken(ctx context.Context, shareToken string) (Collection, error) {
+	row := q.db.QueryRow(ctx, getCollectionByShareToken, shareToken)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Visibility,
+		&i.ShareToken,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getCommunityRating = `-- name: GetCommunityRating :one
+WITH buckets AS (
+  SELECT bucket.rating, COUNT(Review.id) AS votes
+  FROM generate_series(0, 10) AS bucket(rating)
+  LEFT JOIN Review ON Review.movie_id = $1 AND Review.rating = bucket.rating
+  GROUP BY bucket.rating
+)
+SELECT
+  SUM(votes)::int AS review_count,
+  ROUND(SUM(rating * votes) / NULLIF(SUM(votes), 0), 2)::numeric AS mean,
+  ARRAY_AGG(votes::int ORDER BY rating)::int[] AS histogram
+FROM buckets
+`
+
+type GetCommunityRatingRow struct {
+	ReviewCount int32          `json:"review_count"`
+	Mean        pgtype.Numeric `json:"mean"`
+	Histogram   []int32        `json:"histogram"`
+}
+
+// histogram holds the number of reviews for every rating from 0 to 10, mean is null without reviews
+func (q *Queries) GetCommunityRating(ctx context.Context, movieID int32) (GetCommunityRatingRow, error) {
+	row := q.db.QueryRow(ctx, getCommunityRating, movieID)
+	var i GetCommunityRatingRow
+	err := row.Scan(&i.ReviewCount, &i.Mean, &i.Histogram)
+	return i, err
+}
+
+const getMaintenanceRun = `-- name: GetMaintenanceRun :one
+SELECT id, task, status, triggered_by, started_at, finished_at, error FROM MaintenanceRun
+WHERE id = $1
+`
+
+func (q *Queries) GetMaintenanceRun(ctx context.Context, id int32) (Maintenancerun, error) {
+	row := q.db.QueryRow(ctx, getMaintenanceRun, id)
+	var i Maintenancerun
+	err := row.Scan(
+		&i.ID,
+		&i.Task,
+		&i.Status,
+		&i.TriggeredBy,
+		&i.StartedAt,
+		&i.FinishedAt,
+		&i.Error,
+	)
+	return i, err
+}
+
+const getMovie = `-- name: GetMovie :one
+SELECT id, title, description, release_date, rating, language FROM Movie
+WHERE id = $1
+`
+
+func (q *Queries) GetMovie(ctx context.Context, id int32) (Movie, error) {
+	row := q.db.QueryRow(ctx, getMovie, id)
+	var i Movie
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Description,
+		&i.ReleaseDate,
+		&i.Rating,
+		&i.Language,
+	)
+	return i, err
+}
+
+const getRefreshToken = `-- name: GetRefreshToken :one
+SELECT id, family_id, user_id, used, revoked, expires_at, created_at FROM RefreshToken
+WHERE id = $1
+`
+
+func (q *Queries) GetRefreshToken(ctx context.Context, id string) (Refreshtoken, error) {
+	row := q.db.QueryRow(ctx, getRefreshToken, id)
+	var i Refreshtoken
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.Used,
+		&i.Revoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getReview = `-- name: GetReview :one
+SELECT id, movie_id, user_id, rating, body, created_at, updated_at FROM Review
+WHERE id = $1
+`
+
+func (q *Queries) GetReview(ctx context.Context, id int32) (Review, error) {
+	row := q.db.QueryRow(ctx, getReview, id)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getUserById = `-- name: GetUserById :one
+SELECT id, username, password, role, tokens_valid_after, disabled FROM AppUser 
+WHERE id = $1
+`
+
+func (q *Queries) GetUserById(ctx context.Context, id int32) (Appuser, error) {
+	row := q.db.QueryRow(ctx, getUserById, id)
+	var i Appuser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Role,
+		&i.TokensValidAfter,
+		&i.Disabled,
+	)
+	return i, err
+}
+
+const getUserByUsername = `-- name: GetUserByUsername :one
+SELECT id, username, password, role, tokens_valid_after, disabled FROM AppUser 
+WHERE username = $1
+`
+
+func (q *Queries) GetUserByUsername(ctx context.Context, username string) (Appuser, error) {
+	row := q.db.QueryRow(ctx, getUserByUsername, username)
+	var i Appuser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Role,
+		&i.TokensValidAfter,
+		&i.Disabled,
+	)
+	return i, err
+}
+
+const highlightMovies = `-- name: HighlightMovies :many
+SELECT
+  Movie.id,
+  ts_headline(search.config, Movie.title, search.query, $1::text || ', HighlightAll=true')::text AS title,
+  ts_headline(search.config, COALESCE(Movie.description, ''), search.query,
+    $1::text || ', MaxFragments=2, MaxWords=30, MinWords=10')::text AS description
+FROM Movie
+JOIN MovieSearch ON MovieSearch.movie_id = Movie.id
+CROSS JOIN LATERAL (
+  SELECT
+    MovieSearch.config,
+    to_tsquery(MovieSearch.config, $2::text) AS query
+) AS search
+WHERE Movie.id = ANY($3::int[])
+`
+
+type HighlightMoviesParams struct {
+	Markers string  `json:"markers"`
+	Query   string  `json:"query"`
+	Ids     []int32 `json:"ids"`
+}
+
+type HighlightMoviesRow struct {
+	ID          int32  `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// Title and description of the movies with the query terms wrapped into markers.
+// Markers are ts_headline options like StartSel="<b>", StopSel="</b>".
+// Every movie is highlighted with the configuration of its search document.
+func (q *Queries) HighlightMovies(ctx context.Context, arg HighlightMoviesParams) ([]HighlightMoviesRow, error) {
+	rows, err := q.db.Query(ctx, highlightMovies, arg.Markers, arg.Query, arg.Ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []HighlightMoviesRow
+	for rows.Next() {
+		var i HighlightMoviesRow
+		if err := rows.Scan(&i.ID, &i.Title, &i.Description); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const isTokenRevoked = `-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM RevokedToken WHERE jti = $1
+)
+`
+
+func (q *Queries) IsTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	row := q.db.QueryRow(ctx, isTokenRevoked, jti)
+	var exists bool
+	err := row.Scan(&exists)
+	return exists, err
+}
+
+const listActorFilmography = `-- name: ListActorFilmography :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, Credit.characters, Credit.billing_order, Credit.uncredited
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role = 'actor'
+ORDER BY Movie.release_date, Movie.id
+`
+
+type ListActorFilmographyRow struct {
+	Movie        Movie       `json:"movie"`
+	Characters   []string    `json:"characters"`
+	BillingOrder pgtype.Int4 `json:"billing_order"`
+	Uncredited   bool        `json:"uncredited"`
+}
+
+func (q *Queries) ListActorFilmography(ctx context.Context, personID int32) ([]ListActorFilmographyRow, error) {
+	rows, err := q.db.Query(ctx, listActorFilmography, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActorFilmographyRow
+	for rows.Next() {
+		var i ListActorFilmographyRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.Characters,
+			&i.BillingOrder,
+			&i.Uncredited,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActorMovies = `-- name: ListActorMovies :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role = 'actor'
+ORDER BY Movie.release_date, Movie.id
+`
+
+func (q *Queries) ListActorMovies(ctx context.Context, personID int32) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listActorMovies, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Movie
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.ReleaseDate,
+			&i.Rating,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listActors = `-- name: ListActors :many
+SELECT 
+    person.id, person.name, person.gender, person.birth,
+    COALESCE(JSON_AGG(json_build_object(
+        'ID', Movie.id,
+        'title', Movie.title,
+        'plot', Movie.description,
+        'release_date', Movie.release_date,
+        'characters', Credit.characters,
+        'billing_order', Credit.billing_order,
+        'uncredited', Credit.uncredited
+    )) FILTER (WHERE Movie.id IS NOT NULL), '[]'::json) AS movies
+FROM 
+    Person
+LEFT JOIN 
+    Credit ON Person.id = Credit.person_id AND Credit.role = 'actor'
+LEFT JOIN 
+    Movie ON Credit.movie_id = Movie.id
+WHERE
+    Person.id > $1::int
+GROUP BY 
+    Person.id
+ORDER BY
+    Person.id
+LIMIT $2
+`
+
+type ListActorsParams struct {
+	AfterID   int32 `json:"after_id"`
+	PageLimit int32 `json:"page_limit"`
+}
+
+type ListActorsRow struct {
+	ID     int32       `json:"id"`
+	Name   string      `json:"name"`
+	Gender GenderType  `json:"gender"`
+	Birth  pgtype.Date `json:"birth"`
+	Movies []byte      `json:"movies"`
+}
+
+// Every person is listed, movies are the ones they acted in
+func (q *Queries) ListActors(ctx context.Context, arg ListActorsParams) ([]ListActorsRow, error) {
+	rows, err := q.db.Query(ctx, listActors, arg.AfterID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListActorsRow
+	for rows.Next() {
+		var i ListActorsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Gender,
+			&i.Birth,
+			&i.Movies,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCollectionItems = `-- name: ListCollectionItems :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, CollectionItem.position, CollectionItem.note
+FROM CollectionItem
+JOIN Movie ON Movie.id = CollectionItem.movie_id
+WHERE CollectionItem.collection_id = $1
+ORDER BY CollectionItem.position
+`
+
+type ListCollectionItemsRow struct {
+	Movie    Movie       `json:"movie"`
+	Position int32       `json:"position"`
+	Note     pgtype.Text `json:"note"`
+}
+
+func (q *Queries) ListCollectionItems(ctx context.Context, collectionID int32) ([]ListCollectionItemsRow, error) {
+	rows, err := q.db.Query(ctx, listCollectionItems, collectionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCollectionItemsRow
+	for rows.Next() {
+		var i ListCollectionItemsRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.Position,
+			&i.Note,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExistingActorIds = `-- name: ListExistingActorIds :many
+SELECT id FROM Person
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) ListExistingActorIds(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listExistingActorIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExistingGenreIds = `-- name: ListExistingGenreIds :many
+SELECT id FROM Genre
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) ListExistingGenreIds(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listExistingGenreIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExistingMovieIds = `-- name: ListExistingMovieIds :many
+SELECT id FROM Movie
+WHERE id = ANY($1::int[])
+`
+
+func (q *Queries) ListExistingMovieIds(ctx context.Context, ids []int32) ([]int32, error) {
+	rows, err := q.db.Query(ctx, listExistingMovieIds, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int32
+	for rows.Next() {
+		var id int32
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listGenres = `-- name: ListGenres :many
+SELECT Genre.id, Genre.name, COUNT(MovieGenre.movie_id)::int AS movie_count
+FROM Genre
+LEFT JOIN MovieGenre ON MovieGenre.genre_id = Genre.id
+GROUP BY Genre.id
+ORDER BY Genre.name
+`
+
+type ListGenresRow struct {
+	ID         int32  `json:"id"`
+	Name       string `json:"name"`
+	MovieCount int32  `json:"movie_count"`
+}
+
+func (q *Queries) ListGenres(ctx context.Context) ([]ListGenresRow, error) {
+	rows, err := q.db.Query(ctx, listGenres)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListGenresRow
+	for rows.Next() {
+		var i ListGenresRow
+		if err := rows.Scan(&i.ID, &i.Name, &i.MovieCount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMaintenanceRuns = `-- name: ListMaintenanceRuns :many
+SELECT id, task, status, triggered_by, started_at, finished_at, error FROM MaintenanceRun
+ORDER BY id DESC
+LIMIT $1
+`
+
+func (q *Queries) ListMaintenanceRuns(ctx context.Context, limit int32) ([]Maintenancerun, error) {
+	rows, err := q.db.Query(ctx, listMaintenanceRuns, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Maintenancerun
+	for rows.Next() {
+		var i Maintenancerun
+		if err := rows.Scan(
+			&i.ID,
+			&i.Task,
+			&i.Status,
+			&i.TriggeredBy,
+			&i.StartedAt,
+			&i.FinishedAt,
+			&i.Error,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieCast = `-- name: ListMovieCast :many
+SELECT person.id, person.name, person.gender, person.birth, Credit.characters, Credit.billing_order, Credit.uncredited
+FROM Person
+JOIN Credit ON Person.id = Credit.person_id
+WHERE Credit.movie_id = $1 AND Credit.role = 'actor'
+ORDER BY Credit.uncredited, Credit.billing_order NULLS LAST, Person.name, Person.id
+`
+
+type ListMovieCastRow struct {
+	Person       Person      `json:"person"`
+	Characters   []string    `json:"characters"`
+	BillingOrder pgtype.Int4 `json:"billing_order"`
+	Uncredited   bool        `json:"uncredited"`
+}
+
+// Billed actors go first in billing order, uncredited actors go last
+func (q *Queries) ListMovieCast(ctx context.Context, movieID int32) ([]ListMovieCastRow, error) {
+	rows, err := q.db.Query(ctx, listMovieCast, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMovieCastRow
+	for rows.Next() {
+		var i ListMovieCastRow
+		if err := rows.Scan(
+			&i.Person.ID,
+			&i.Person.Name,
+			&i.Person.Gender,
+			&i.Person.Birth,
+			&i.Characters,
+			&i.BillingOrder,
+			&i.Uncredited,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieCrew = `-- name: ListMovieCrew :many
+SELECT person.id, person.name, person.gender, person.birth, Credit.role
+FROM Person
+JOIN Credit ON Person.id = Credit.person_id
+WHERE Credit.movie_id = $1 AND Credit.role <> 'actor'
+ORDER BY Credit.role, Person.name, Person.id
+`
+
+type ListMovieCrewRow struct {
+	Person Person     `json:"person"`
+	Role   CreditRole `json:"role"`
+}
+
+// Credits of the movie other than acting
+func (q *Queries) ListMovieCrew(ctx context.Context, movieID int32) ([]ListMovieCrewRow, error) {
+	rows, err := q.db.Query(ctx, listMovieCrew, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMovieCrewRow
+	for rows.Next() {
+		var i ListMovieCrewRow
+		if err := rows.Scan(
+			&i.Person.ID,
+			&i.Person.Name,
+			&i.Person.Gender,
+			&i.Person.Birth,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieGenres = `-- name: ListMovieGenres :many
+SELECT genre.id, genre.name
+FROM Genre
+JOIN MovieGenre ON MovieGenre.genre_id = Genre.id
+WHERE MovieGenre.movie_id = $1
+ORDER BY Genre.name
+`
+
+func (q *Queries) ListMovieGenres(ctx context.Context, movieID int32) ([]Genre, error) {
+	rows, err := q.db.Query(ctx, listMovieGenres, movieID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Genre
+	for rows.Next() {
+		var i Genre
+		if err := rows.Scan(&i.ID, &i.Name); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovieReviews = `-- name: ListMovieReviews :many
+SELECT review.id, review.movie_id, review.user_id, review.rating, review.body, review.created_at, review.updated_at, AppUser.username
+FROM Review
+JOIN AppUser ON AppUser.id = Review.user_id
+WHERE Review.movie_id = $1
+  AND ($2::int IS NULL OR Review.id < $2::int)
+ORDER BY Review.id DESC
+LIMIT $3
+`
+
+type ListMovieReviewsParams struct {
+	MovieID   int32       `json:"movie_id"`
+	BeforeID  pgtype.Int4 `json:"before_id"`
+	PageLimit int32       `json:"page_limit"`
+}
+
+type ListMovieReviewsRow struct {
+	Review   Review `json:"review"`
+	Username string `json:"username"`
+}
+
+// Newest reviews first
+func (q *Queries) ListMovieReviews(ctx context.Context, arg ListMovieReviewsParams) ([]ListMovieReviewsRow, error) {
+	rows, err := q.db.Query(ctx, listMovieReviews, arg.MovieID, arg.BeforeID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListMovieReviewsRow
+	for rows.Next() {
+		var i ListMovieReviewsRow
+		if err := rows.Scan(
+			&i.Review.ID,
+			&i.Review.MovieID,
+			&i.Review.UserID,
+			&i.Review.Rating,
+			&i.Review.Body,
+			&i.Review.CreatedAt,
+			&i.Review.UpdatedAt,
+			&i.Username,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMovies = `-- name: ListMovies :many
+WITH candidates AS (
+  SELECT Movie.id, Movie.title, Movie.release_date, Movie.rating, false AS is_cursor
+  FROM filter_movies(
+      $1::date,
+      $2::date,
+      $3::numeric,
+      $4::numeric,
+      $5::bool,
+      $6::text,
+      $7::int[],
+      $8::int[],
+      $9::int[]
+    ) AS filtered(id)
+  JOIN Movie ON Movie.id = filtered.id
+  UNION ALL
+  SELECT
+    $10::int,
+    $11::text,
+    $12::date,
+    $13::numeric,
+    true
+  WHERE $10::int IS NOT NULL
+), keyed AS (
+  SELECT
+    id,
+    is_cursor,
+    CASE $14::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> $15::bool AS key_null_1,
+    CASE $14::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN $16::bool THEN -1 ELSE 1 END AS key_number_1,
+    CASE WHEN $14::text = 'title' AND NOT $16::bool THEN title END AS key_asc_1,
+    CASE WHEN $14::text = 'title' AND $16::bool THEN title END AS key_desc_1,
+    CASE $17::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> $15::bool AS key_null_2,
+    CASE $17::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN $18::bool THEN -1 ELSE 1 END AS key_number_2,
+    CASE WHEN $17::text = 'title' AND NOT $18::bool THEN title END AS key_asc_2,
+    CASE WHEN $17::text = 'title' AND $18::bool THEN title END AS key_desc_2,
+    CASE $19::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> $15::bool AS key_null_3,
+    CASE $19::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN $20::bool THEN -1 ELSE 1 END AS key_number_3,
+    CASE WHEN $19::text = 'title' AND NOT $20::bool THEN title END AS key_asc_3,
+    CASE WHEN $19::text = 'title' AND $20::bool THEN title END AS key_desc_3
+  FROM candidates
+)
+SELECT Movie.*
+FROM keyed k
+JOIN Movie ON Movie.id = k.id AND NOT k.is_cursor
+LEFT JOIN keyed c ON c.is_cursor
+WHERE c.id IS NULL OR (
+    CASE
+      WHEN k.key_null_1 <> c.key_null_1 THEN CASE WHEN k.key_null_1 THEN 1 ELSE -1 END
+      WHEN k.key_number_1 <> c.key_number_1 THEN sign(k.key_number_1 - c.key_number_1)::int
+      WHEN k.key_asc_1 <> c.key_asc_1 THEN CASE WHEN k.key_asc_1 > c.key_asc_1 THEN 1 ELSE -1 END
+      WHEN k.key_desc_1 <> c.key_desc_1 THEN CASE WHEN k.key_desc_1 < c.key_desc_1 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    CASE
+      WHEN k.key_null_2 <> c.key_null_2 THEN CASE WHEN k.key_null_2 THEN 1 ELSE -1 END
+      WHEN k.key_number_2 <> c.key_number_2 THEN sign(k.key_number_2 - c.key_number_2)::int
+      WHEN k.key_asc_2 <> c.key_asc_2 THEN CASE WHEN k.key_asc_2 > c.key_asc_2 THEN 1 ELSE -1 END
+      WHEN k.key_desc_2 <> c.key_desc_2 THEN CASE WHEN k.key_desc_2 < c.key_desc_2 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    CASE
+      WHEN k.key_null_3 <> c.key_null_3 THEN CASE WHEN k.key_null_3 THEN 1 ELSE -1 END
+      WHEN k.key_number_3 <> c.key_number_3 THEN sign(k.key_number_3 - c.key_number_3)::int
+      WHEN k.key_asc_3 <> c.key_asc_3 THEN CASE WHEN k.key_asc_3 > c.key_asc_3 THEN 1 ELSE -1 END
+      WHEN k.key_desc_3 <> c.key_desc_3 THEN CASE WHEN k.key_desc_3 < c.key_desc_3 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    sign(k.id - c.id)::int
+  ) > (0, 0, 0, 0)
+ORDER BY
+  k.key_null_1, k.key_number_1, k.key_asc_1, k.key_desc_1 DESC,
+  k.key_null_2, k.key_number_2, k.key_asc_2, k.key_desc_2 DESC,
+  k.key_null_3, k.key_number_3, k.key_asc_3, k.key_desc_3 DESC,
+  k.id
+LIMIT $21
+`
+
+type ListMoviesParams struct {
+	ReleasedAfter     pgtype.Date    `json:"released_after"`
+	ReleasedBefore    pgtype.Date    `json:"released_before"`
+	MinRating         pgtype.Numeric `json:"min_rating"`
+	MaxRating         pgtype.Numeric `json:"max_rating"`
+	HasDescription    pgtype.Bool    `json:"has_description"`
+	TitlePrefix       pgtype.Text    `json:"title_prefix"`
+	ActorIds          []int32        `json:"actor_ids"`
+	DirectorIds       []int32        `json:"director_ids"`
+	GenreIds          []int32        `json:"genre_ids"`
+	CursorID          pgtype.Int4    `json:"cursor_id"`
+	CursorTitle       pgtype.Text    `json:"cursor_title"`
+	CursorReleaseDate pgtype.Date    `json:"cursor_release_date"`
+	CursorRating      pgtype.Numeric `json:"cursor_rating"`
+	SortKey1          string         `json:"sort_key_1"`
+	NullsFirst        bool           `json:"nulls_first"`
+	SortDesc1         bool           `json:"sort_desc_1"`
+	SortKey2          string         `json:"sort_key_2"`
+	SortDesc2         bool           `json:"sort_desc_2"`
+	SortKey3          string         `json:"sort_key_3"`
+	SortDesc3         bool           `json:"sort_desc_3"`
+	PageLimit         int32          `json:"page_limit"`
+}
+
+// Keyset pagination over up to three sort keys chosen at runtime, an empty key is ignored.
+// It sorts every filtered movie, so listings with a single key use ListMoviesByKey instead.
+// The cursor movie goes through the same key expressions as the listed movies,
+// then every key of a listed movie is compared with the key of the cursor to 1 (after), -1 (before)
+// or 0 (equal) and the first difference decides whether it sorts after the cursor. Ties are broken by id.
+// Filters are skipped when their parameter is null, see filter_movies.
+func (q *Queries) ListMovies(ctx context.Context, arg ListMoviesParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMovies,
+		arg.ReleasedAfter,
+		arg.ReleasedBefore,
+		arg.MinRating,
+		arg.MaxRating,
+		arg.HasDescription,
+		arg.TitlePrefix,
+		arg.ActorIds,
+		arg.DirectorIds,
+		arg.GenreIds,
+		arg.CursorID,
+		arg.CursorTitle,
+		arg.CursorReleaseDate,
+		arg.CursorRating,
+		arg.SortKey1,
+		arg.NullsFirst,
+		arg.SortDesc1,
+		arg.SortKey2,
+		arg.SortDesc2,
+		arg.SortKey3,
+		arg.SortDesc3,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Movie
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.ReleaseDate,
+			&i.Rating,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listMoviesByKey = `-- name: ListMoviesByKey :many
+WITH filtered AS NOT MATERIALIZED (
+  SELECT filtered.id
+  FROM filter_movies(
+      $1::date,
+      $2::date,
+      $3::numeric,
+      $4::numeric,
+      $5::bool,
+      $6::text,
+      $7::int[],
+      $8::int[],
+      $9::int[]
+    ) AS filtered(id)
+)
+SELECT id, title, description, release_date, rating, language
+FROM (
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, $10::bool AS segment, row_number() OVER (ORDER BY Movie.rating, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'rating'
+      AND NOT $12::bool
+      AND Movie.rating IS NOT NULL
+      AND ($13::int IS NULL
+        OR $14::numeric IS NULL AND $10::bool
+        OR (Movie.rating, Movie.id) > ($14::numeric, $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.rating, Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, $10::bool AS segment, row_number() OVER (ORDER BY Movie.rating DESC, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'rating'
+      AND $12::bool
+      AND Movie.rating IS NOT NULL
+      AND ($13::int IS NULL
+        OR $14::numeric IS NULL AND $10::bool
+        OR Movie.rating <= $14::numeric
+          AND (Movie.rating < $14::numeric OR Movie.id > $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.rating DESC, Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, NOT $10::bool AS segment, row_number() OVER (ORDER BY Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'rating'
+      AND Movie.rating IS NULL
+      AND ($13::int IS NULL
+        OR $14::numeric IS NOT NULL AND NOT $10::bool
+        OR $14::numeric IS NULL AND Movie.id > $13::int)
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, $10::bool AS segment, row_number() OVER (ORDER BY Movie.release_date, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'date'
+      AND NOT $12::bool
+      AND Movie.release_date IS NOT NULL
+      AND ($13::int IS NULL
+        OR $16::date IS NULL AND $10::bool
+        OR (Movie.release_date, Movie.id) > ($16::date, $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.release_date, Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, $10::bool AS segment, row_number() OVER (ORDER BY Movie.release_date DESC, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'date'
+      AND $12::bool
+      AND Movie.release_date IS NOT NULL
+      AND ($13::int IS NULL
+        OR $16::date IS NULL AND $10::bool
+        OR Movie.release_date <= $16::date
+          AND (Movie.release_date < $16::date OR Movie.id > $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.release_date DESC, Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, NOT $10::bool AS segment, row_number() OVER (ORDER BY Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'date'
+      AND Movie.release_date IS NULL
+      AND ($13::int IS NULL
+        OR $16::date IS NOT NULL AND NOT $10::bool
+        OR $16::date IS NULL AND Movie.id > $13::int)
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, false AS segment, row_number() OVER (ORDER BY Movie.title, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'title'
+      AND NOT $12::bool
+      AND ($13::int IS NULL
+        OR (Movie.title, Movie.id) > ($17::text, $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.title, Movie.id
+    LIMIT $15
+  )
+  UNION ALL
+  (
+    SELECT Movie.id, Movie.title, Movie.description, Movie.release_date, Movie.rating, Movie.language, false AS segment, row_number() OVER (ORDER BY Movie.title DESC, Movie.id) AS position
+    FROM Movie
+    WHERE $11::text = 'title'
+      AND $12::bool
+      AND ($13::int IS NULL
+        OR Movie.title <= $17::text
+          AND (Movie.title < $17::text OR Movie.id > $13::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.title DESC, Movie.id
+    LIMIT $15
+  )
+) AS page
+ORDER BY segment, position
+LIMIT $15
+`
+
+type ListMoviesByKeyParams struct {
+	ReleasedAfter     pgtype.Date    `json:"released_after"`
+	ReleasedBefore    pgtype.Date    `json:"released_before"`
+	MinRating         pgtype.Numeric `json:"min_rating"`
+	MaxRating         pgtype.Numeric `json:"max_rating"`
+	HasDescription    pgtype.Bool    `json:"has_description"`
+	TitlePrefix       pgtype.Text    `json:"title_prefix"`
+	ActorIds          []int32        `json:"actor_ids"`
+	DirectorIds       []int32        `json:"director_ids"`
+	GenreIds          []int32        `json:"genre_ids"`
+	NullsFirst        bool           `json:"nulls_first"`
+	SortKey           string         `json:"sort_key"`
+	SortDesc          bool           `json:"sort_desc"`
+	CursorID          pgtype.Int4    `json:"cursor_id"`
+	CursorRating      pgtype.Numeric `json:"cursor_rating"`
+	PageLimit         int32          `json:"page_limit"`
+	CursorReleaseDate pgtype.Date    `json:"cursor_release_date"`
+	CursorTitle       pgtype.Text    `json:"cursor_title"`
+}
+
+// ListMovies for a single sort key. Every branch reads one segment of one order straight from
+// the sort indexes of Movie and stops at the page size, branches of other orders are skipped.
+// Movies with an empty key form their own segment ordered by id, nulls_first places it first.
+// Only the few rows of the active branches are sorted into the page.
+func (q *Queries) ListMoviesByKey(ctx context.Context, arg ListMoviesByKeyParams) ([]Movie, error) {
+	rows, err := q.db.Query(ctx, listMoviesByKey,
+		arg.ReleasedAfter,
+		arg.ReleasedBefore,
+		arg.MinRating,
+		arg.MaxRating,
+		arg.HasDescription,
+		arg.TitlePrefix,
+		arg.ActorIds,
+		arg.DirectorIds,
+		arg.GenreIds,
+		arg.NullsFirst,
+		arg.SortKey,
+		arg.SortDesc,
+		arg.CursorID,
+		arg.CursorRating,
+		arg.PageLimit,
+		arg.CursorReleaseDate,
+		arg.CursorTitle,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Movie
+	for rows.Next() {
+		var i Movie
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Description,
+			&i.ReleaseDate,
+			&i.Rating,
+			&i.Language,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPersonCrewMovies = `-- name: ListPersonCrewMovies :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, Credit.role
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role <> 'actor'
+ORDER BY Movie.release_date, Movie.id, Credit.role
+`
+
+type ListPersonCrewMoviesRow struct {
+	Movie Movie      `json:"movie"`
+	Role  CreditRole `json:"role"`
+}
+
+// Movies the person worked on in roles other than acting
+func (q *Queries) ListPersonCrewMovies(ctx context.Context, personID int32) ([]ListPersonCrewMoviesRow, error) {
+	rows, err := q.db.Query(ctx, listPersonCrewMovies, personID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListPersonCrewMoviesRow
+	for rows.Next() {
+		var i ListPersonCrewMoviesRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.Role,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserCollections = `-- name: ListUserCollections :many
+SELECT id, user_id, name, description, visibility, share_token, created_at, updated_at FROM Collection
+WHERE user_id = $1
+  AND ($2::int IS NULL OR id < $2::int)
+ORDER BY id DESC
+LIMIT $3
+`
+
+type ListUserCollectionsParams struct {
+	UserID    int32       `json:"user_id"`
+	BeforeID  pgtype.Int4 `json:"before_id"`
+	PageLimit int32       `json:"page_limit"`
+}
+
+// Newest collections first
+func (q *Queries) ListUserCollections(ctx context.Context, arg ListUserCollectionsParams) ([]Collection, error) {
+	rows, err := q.db.Query(ctx, listUserCollections, arg.UserID, arg.BeforeID, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Collection
+	for rows.Next() {
+		var i Collection
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.Name,
+			&i.Description,
+			&i.Visibility,
+			&i.ShareToken,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUserMovieFlags = `-- name: ListUserMovieFlags :many
+SELECT
+  Movie.id,
+  EXISTS (
+    SELECT 1 FROM Watchlist
+    WHERE Watchlist.user_id = $1 AND Watchlist.movie_id = Movie.id
+  ) AS in_watchlist,
+  EXISTS (
+    SELECT 1 FROM Watched
+    WHERE Watched.user_id = $1 AND Watched.movie_id = Movie.id
+  ) AS watched
+FROM Movie
+WHERE Movie.id = ANY($2::int[])
+`
+
+type ListUserMovieFlagsParams struct {
+	UserID   int32   `json:"user_id"`
+	MovieIds []int32 `json:"movie_ids"`
+}
+
+type ListUserMovieFlagsRow struct {
+	ID          int32 `json:"id"`
+	InWatchlist bool  `json:"in_watchlist"`
+	Watched     bool  `json:"watched"`
+}
+
+// Watchlist and watched state of the given movies for the user
+func (q *Queries) ListUserMovieFlags(ctx context.Context, arg ListUserMovieFlagsParams) ([]ListUserMovieFlagsRow, error) {
+	rows, err := q.db.Query(ctx, listUserMovieFlags, arg.UserID, arg.MovieIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListUserMovieFlagsRow
+	for rows.Next() {
+		var i ListUserMovieFlagsRow
+		if err := rows.Scan(&i.ID, &i.InWatchlist, &i.Watched); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUsers = `-- name: ListUsers :many
+SELECT id, username, password, role, tokens_valid_after, disabled FROM AppUser
+ORDER BY id
+`
+
+func (q *Queries) ListUsers(ctx context.Context) ([]Appuser, error) {
+	rows, err := q.db.Query(ctx, listUsers)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Appuser
+	for rows.Next() {
+		var i Appuser
+		if err := rows.Scan(
+			&i.ID,
+			&i.Username,
+			&i.Password,
+			&i.Role,
+			&i.TokensValidAfter,
+			&i.Disabled,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatched = `-- name: ListWatched :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, Watched.watched_on, Watched.rewatch_count
+FROM Watched
+JOIN Movie ON Movie.id = Watched.movie_id
+WHERE Watched.user_id = $1
+  AND ($2::date IS NULL
+    OR (Watched.watched_on, Watched.movie_id) < ($2::date, $3::int))
+ORDER BY Watched.watched_on DESC, Watched.movie_id DESC
+LIMIT $4
+`
+
+type ListWatchedParams struct {
+	UserID          int32       `json:"user_id"`
+	BeforeWatchedOn pgtype.Date `json:"before_watched_on"`
+	BeforeMovieID   pgtype.Int4 `json:"before_movie_id"`
+	PageLimit       int32       `json:"page_limit"`
+}
+
+type ListWatchedRow struct {
+	Movie        Movie       `json:"movie"`
+	WatchedOn    pgtype.Date `json:"watched_on"`
+	RewatchCount int32       `json:"rewatch_count"`
+}
+
+// Recently watched movies first
+func (q *Queries) ListWatched(ctx context.Context, arg ListWatchedParams) ([]ListWatchedRow, error) {
+	rows, err := q.db.Query(ctx, listWatched,
+		arg.UserID,
+		arg.BeforeWatchedOn,
+		arg.BeforeMovieID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListWatchedRow
+	for rows.Next() {
+		var i ListWatchedRow
+		if err := rows.Scan(
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.WatchedOn,
+			&i.RewatchCount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchlist = `-- name: ListWatchlist :many
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, Watchlist.added_at
+FROM Watchlist
+JOIN Movie ON Movie.id = Watchlist.movie_id
+WHERE Watchlist.user_id = $1
+  AND ($2::timestamptz IS NULL
+    OR (Watchlist.added_at, Watchlist.movie_id) < ($2::timestamptz, $3::int))
+ORDER BY Watchlist.added_at DESC, Watchlist.movie_id DESC
+LIMIT $4
+`
+
+type ListWatchlistParams struct {
+	UserID        int32              `json:"user_id"`
+	BeforeAddedAt pgtype.Timestamptz `json:"before_added_at"`
+	BeforeMovieID pgtype.Int4        `json:"before_movie_id"`
+	PageLimit     int32              `json:"page_limit"`
+}
+
+type ListWatchlistRow struct {
+	Movie   Movie              `json:"movie"`
+	AddedAt pgtype.Timestamptz `json:"added_at"`
+}
+
+// Recently added movies first
+func (q *Queries) ListWatchlist(ctx context.Context, arg ListWatchlistParams) ([]ListWatchlistRow, error) {
+	rows, err := q.db.Query(ctx, listWatchlist,
+		arg.UserID,
+		arg.BeforeAddedAt,
+		arg.BeforeMovieID,
+		arg.PageLimit,
+	)
+	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []ListActorsRow
+	var items []ListWatchlistRow
 	for rows.Next() {
-		var i ListActorsRow
+		var i ListWatchlistRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Name,
-			&i.Gender,
-			&i.Birth,
-			&i.Movies,
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.AddedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -287,26 +2271,333 @@ func (q *Queries) ListActors(ctx context.Context) ([]ListActorsRow, error) {
 	return items, nil
 }
 
-const listMoviesAsc = `-- name: ListMoviesAsc :many
-SELECT id, title, description, release_date, rating FROM Movie
-ORDER BY $1::text ASC
+const lockActor = `-- name: LockActor :one
+SELECT id FROM Person
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockActor(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, lockActor, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const lockCollection = `-- name: LockCollection :one
+SELECT id FROM Collection
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockCollection(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, lockCollection, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const lockMovie = `-- name: LockMovie :one
+SELECT id FROM Movie
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockMovie(ctx context.Context, id int32) (int32, error) {
+	row := q.db.QueryRow(ctx, lockMovie, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const logWatched = `-- name: LogWatched :one
+INSERT INTO Watched (user_id, movie_id, watched_on, rewatch_count)
+VALUES (
+  $1,
+  $2,
+  COALESCE($3::date, CURRENT_DATE),
+  COALESCE($4::int, 0)
+)
+ON CONFLICT (user_id, movie_id) DO UPDATE
+SET watched_on = GREATEST(Watched.watched_on, EXCLUDED.watched_on),
+    rewatch_count = COALESCE($4::int, Watched.rewatch_count + 1)
+RETURNING user_id, movie_id, watched_on, rewatch_count
+`
+
+type LogWatchedParams struct {
+	UserID       int32       `json:"user_id"`
+	MovieID      int32       `json:"movie_id"`
+	WatchedOn    pgtype.Date `json:"watched_on"`
+	RewatchCount pgtype.Int4 `json:"rewatch_count"`
+}
+
+// Logging a watched movie again keeps the latest date and counts a rewatch unless the count is given
+func (q *Queries) LogWatched(ctx context.Context, arg LogWatchedParams) (Watched, error) {
+	row := q.db.QueryRow(ctx, logWatched,
+		arg.UserID,
+		arg.MovieID,
+		arg.WatchedOn,
+		arg.RewatchCount,
+	)
+	var i Watched
+	err := row.Scan(
+		&i.UserID,
+		&i.MovieID,
+		&i.WatchedOn,
+		&i.RewatchCount,
+	)
+	return i, err
+}
+
+const removeFromWatchlist = `-- name: RemoveFromWatchlist :one
+DELETE FROM Watchlist
+WHERE user_id = $1 AND movie_id = $2
+RETURNING movie_id
+`
+
+type RemoveFromWatchlistParams struct {
+	UserID  int32 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+func (q *Queries) RemoveFromWatchlist(ctx context.Context, arg RemoveFromWatchlistParams) (int32, error) {
+	row := q.db.QueryRow(ctx, removeFromWatchlist, arg.UserID, arg.MovieID)
+	var movie_id int32
+	err := row.Scan(&movie_id)
+	return movie_id, err
+}
+
+const removeWatched = `-- name: RemoveWatched :one
+DELETE FROM Watched
+WHERE user_id = $1 AND movie_id = $2
+RETURNING movie_id
+`
+
+type RemoveWatchedParams struct {
+	UserID  int32 `json:"user_id"`
+	MovieID int32 `json:"movie_id"`
+}
+
+func (q *Queries) RemoveWatched(ctx context.Context, arg RemoveWatchedParams) (int32, error) {
+	row := q.db.QueryRow(ctx, removeWatched, arg.UserID, arg.MovieID)
+	var movie_id int32
+	err := row.Scan(&movie_id)
+	return movie_id, err
+}
+
+const reorderCollectionItems = `-- name: ReorderCollectionItems :execrows
+UPDATE CollectionItem
+SET position = ordered.position::int
+FROM unnest($1::int[]) WITH ORDINALITY AS ordered(movie_id, position)
+WHERE CollectionItem.collection_id = $2
+  AND CollectionItem.movie_id = ordered.movie_id
+`
+
+type ReorderCollectionItemsParams struct {
+	MovieIds     []int32 `json:"movie_ids"`
+	CollectionID int32   `json:"collection_id"`
+}
+
+// Items get positions in the order of movie_ids, starting from 1
+func (q *Queries) ReorderCollectionItems(ctx context.Context, arg ReorderCollectionItemsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, reorderCollectionItems, arg.MovieIds, arg.CollectionID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const revokeRefreshTokenFamily = `-- name: RevokeRefreshTokenFamily :exec
+UPDATE RefreshToken
+  SET revoked = true
+WHERE family_id = $1
+`
+
+func (q *Queries) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	_, err := q.db.Exec(ctx, revokeRefreshTokenFamily, familyID)
+	return err
+}
+
+const revokeToken = `-- name: RevokeToken :exec
+INSERT INTO RevokedToken (
+  jti, user_id, expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (jti) DO NOTHING
+`
+
+type RevokeTokenParams struct {
+	Jti       string             `json:"jti"`
+	UserID    int32              `json:"user_id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) RevokeToken(ctx context.Context, arg RevokeTokenParams) error {
+	_, err := q.db.Exec(ctx, revokeToken, arg.Jti, arg.UserID, arg.ExpiresAt)
+	return err
+}
+
+const revokeUserRefreshTokens = `-- name: RevokeUserRefreshTokens :exec
+UPDATE RefreshToken
+  SET revoked = true
+WHERE user_id = $1
+`
+
+func (q *Queries) RevokeUserRefreshTokens(ctx context.Context, userID int32) error {
+	_, err := q.db.Exec(ctx, revokeUserRefreshTokens, userID)
+	return err
+}
+
+const revokeUserTokens = `-- name: RevokeUserTokens :exec
+UPDATE AppUser
+  SET tokens_valid_after = $2
+WHERE id = $1
+`
+
+type RevokeUserTokensParams struct {
+	ID               int32              `json:"id"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+}
+
+func (q *Queries) RevokeUserTokens(ctx context.Context, arg RevokeUserTokensParams) error {
+	_, err := q.db.Exec(ctx, revokeUserTokens, arg.ID, arg.TokensValidAfter)
+	return err
+}
+
+const searchMovie = `-- name: SearchMovie :many
+WITH search AS (
+  SELECT
+    to_tsquery('english', $1::text) AS english,
+    to_tsquery('russian', $1::text) AS russian,
+    to_tsquery('simple', $1::text) AS simple
+), matches AS (
+  SELECT
+    MovieSearch.movie_id,
+    COALESCE(ts_rank_cd(MovieSearch.document, CASE MovieSearch.config
+      WHEN 'english'::regconfig THEN search.english
+      WHEN 'russian'::regconfig THEN search.russian
+      ELSE search.simple
+    END), 0)::real AS score
+  FROM MovieSearch, search
+  WHERE $1::text IS NULL
+    OR (MovieSearch.config = 'english'::regconfig AND MovieSearch.document @@ search.english)
+    OR (MovieSearch.config = 'russian'::regconfig AND MovieSearch.document @@ search.russian)
+    OR (MovieSearch.config = 'simple'::regconfig AND MovieSearch.document @@ search.simple)
+)
+SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating, movie.language, matches.score
+FROM matches
+JOIN Movie ON Movie.id = matches.movie_id
+WHERE ($2::real IS NULL
+    OR matches.score < $2::real
+    OR (matches.score = $2::real AND Movie.id > $3::int))
+  AND ($4::text IS NULL OR Movie.language = $4::text)
+  AND ($5::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) >= $5::int)
+  AND ($6::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) <= $6::int)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($7::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest($8::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($9::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest($10::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest($11::int[]) AS wanted(genre_id)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM MovieGenre
+      WHERE MovieGenre.movie_id = Movie.id AND MovieGenre.genre_id = wanted.genre_id
+    )
+  )
+ORDER BY matches.score DESC, Movie.id
+LIMIT $12
 `
 
-func (q *Queries) ListMoviesAsc(ctx context.Context, property string) ([]Movie, error) {
-	rows, err := q.db.Query(ctx, listMoviesAsc, property)
+type SearchMovieParams struct {
+	Query             pgtype.Text   `json:"query"`
+	AfterScore        pgtype.Float4 `json:"after_score"`
+	AfterID           int32         `json:"after_id"`
+	Language          pgtype.Text   `json:"language"`
+	YearFrom          pgtype.Int4   `json:"year_from"`
+	YearTo            pgtype.Int4   `json:"year_to"`
+	Actors            []string      `json:"actors"`
+	ExcludedActors    []string      `json:"excluded_actors"`
+	Directors         []string      `json:"directors"`
+	ExcludedDirectors []string      `json:"excluded_directors"`
+	GenreIds          []int32       `json:"genre_ids"`
+	PageLimit         int32         `json:"page_limit"`
+}
+
+type SearchMovieRow struct {
+	Movie Movie   `json:"movie"`
+	Score float32 `json:"score"`
+}
+
+// The query is tsquery text, every movie matches when it is null.
+// The query is built once for every configuration a document can have and each document
+// is matched with the query of its own configuration, so every branch can use the document index.
+// With a language only movies of the language are searched.
+// Movies must have a cast member containing every name of actors and none of excluded_actors,
+// a director containing every name of directors and none of excluded_directors,
+// and every genre of genre_ids.
+// Results are ordered by relevance, then by id.
+func (q *Queries) SearchMovie(ctx context.Context, arg SearchMovieParams) ([]SearchMovieRow, error) {
+	rows, err := q.db.Query(ctx, searchMovie,
+		arg.Query,
+		arg.AfterScore,
+		arg.AfterID,
+		arg.Language,
+		arg.YearFrom,
+		arg.YearTo,
+		arg.Actors,
+		arg.ExcludedActors,
+		arg.Directors,
+		arg.ExcludedDirectors,
+		arg.GenreIds,
+		arg.PageLimit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Movie
+	var items []SearchMovieRow
 	for rows.Next() {
-		var i Movie
+		var i SearchMovieRow
 		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.ReleaseDate,
-			&i.Rating,
+			&i.Movie.ID,
+			&i.Movie.Title,
+			&i.Movie.Description,
+			&i.Movie.ReleaseDate,
+			&i.Movie.Rating,
+			&i.Movie.Language,
+			&i.Score,
 		); err != nil {
 			return nil, err
 		}
@@ -318,27 +2609,44 @@ func (q *Queries) ListMoviesAsc(ctx context.Context, property string) ([]Movie,
 	return items, nil
 }
 
-const listMoviesDesc = `-- name: ListMoviesDesc :many
-SELECT id, title, description, release_date, rating FROM Movie
-ORDER BY $1::text DESC
+const searchSuggestions = `-- name: SearchSuggestions :many
+SELECT suggestion::text, MAX(score)::real AS score
+FROM (
+  SELECT Movie.title AS suggestion, word_similarity($1::text, Movie.title) AS score
+  FROM Movie
+  WHERE $1::text <% Movie.title
+  UNION ALL
+  SELECT Person.name, word_similarity($1::text, Person.name)
+  FROM Person
+  WHERE $1::text <% Person.name
+) AS candidates
+GROUP BY suggestion
+ORDER BY score DESC, suggestion
+LIMIT $2
 `
 
-func (q *Queries) ListMoviesDesc(ctx context.Context, property string) ([]Movie, error) {
-	rows, err := q.db.Query(ctx, listMoviesDesc, property)
+type SearchSuggestionsParams struct {
+	Query           string `json:"query"`
+	SuggestionLimit int32  `json:"suggestion_limit"`
+}
+
+type SearchSuggestionsRow struct {
+	Suggestion string  `json:"suggestion"`
+	Score      float32 `json:"score"`
+}
+
+// Movie titles and names of people similar to the query, most similar first.
+// Run SetSimilarityThreshold in the same transaction to change the threshold.
+func (q *Queries) SearchSuggestions(ctx context.Context, arg SearchSuggestionsParams) ([]SearchSuggestionsRow, error) {
+	rows, err := q.db.Query(ctx, searchSuggestions, arg.Query, arg.SuggestionLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Movie
+	var items []SearchSuggestionsRow
 	for rows.Next() {
-		var i Movie
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.ReleaseDate,
-			&i.Rating,
-		); err != nil {
+		var i SearchSuggestionsRow
+		if err := rows.Scan(&i.Suggestion, &i.Score); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -349,40 +2657,123 @@ func (q *Queries) ListMoviesDesc(ctx context.Context, property string) ([]Movie,
 	return items, nil
 }
 
-const searchMovie = `-- name: SearchMovie :many
-SELECT movie.id, movie.title, movie.description, movie.release_date, movie.rating
-FROM Movie 
-LEFT JOIN 
-    MovieActor ON Movie.id = MovieActor.movie_id
-LEFT JOIN 
-    Actor ON MovieActor.actor_id = Actor.id
-WHERE 
-  title @@ to_tsquery($1)
-OR
-  LOWER(actor.name) LIKE LOWER($2)
+const setCollectionShareToken = `-- name: SetCollectionShareToken :one
+UPDATE Collection
+SET share_token = $1, updated_at = now()
+WHERE id = $2
+RETURNING id, user_id, name, description, visibility, share_token, created_at, updated_at
 `
 
-type SearchMovieParams struct {
-	ToTsquery string `json:"to_tsquery"`
-	Lower     string `json:"lower"`
+type SetCollectionShareTokenParams struct {
+	ShareToken string `json:"share_token"`
+	ID         int32  `json:"id"`
+}
+
+func (q *Queries) SetCollectionShareToken(ctx context.Context, arg SetCollectionShareTokenParams) (Collection, error) {
+	row := q.db.QueryRow(ctx, setCollectionShareToken, arg.ShareToken, arg.ID)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Visibility,
+		&i.ShareToken,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setSimilarityThreshold = `-- name: SetSimilarityThreshold :exec
+SELECT set_config('pg_trgm.word_similarity_threshold', ($1::real)::text, true)
+`
+
+// Threshold of the <% operator for the rest of the transaction
+func (q *Queries) SetSimilarityThreshold(ctx context.Context, threshold float32) error {
+	_, err := q.db.Exec(ctx, setSimilarityThreshold, threshold)
+	return err
+}
+
+const setUserDisabled = `-- name: SetUserDisabled :one
+UPDATE AppUser
+  SET disabled = $2
+WHERE id = $1
+RETURNING id, username, password, role, tokens_valid_after, disabled
+`
+
+type SetUserDisabledParams struct {
+	ID       int32 `json:"id"`
+	Disabled bool  `json:"disabled"`
+}
+
+func (q *Queries) SetUserDisabled(ctx context.Context, arg SetUserDisabledParams) (Appuser, error) {
+	row := q.db.QueryRow(ctx, setUserDisabled, arg.ID, arg.Disabled)
+	var i Appuser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Role,
+		&i.TokensValidAfter,
+		&i.Disabled,
+	)
+	return i, err
+}
+
+const suggest = `-- name: Suggest :many
+WITH bounds AS (
+  SELECT LOWER($1::text) AS low, LOWER($1::text) || chr(1114111) AS high
+)
+SELECT kind::text, id, label::text
+FROM (
+  (
+    SELECT 'movie' AS kind, Movie.id, Movie.title AS label
+    FROM Movie, bounds
+    WHERE LOWER(Movie.title) ~>=~ bounds.low AND LOWER(Movie.title) ~<~ bounds.high
+    ORDER BY LOWER(Movie.title) USING ~<~
+    LIMIT $2
+  )
+  UNION ALL
+  (
+    SELECT 'actor', Person.id, Person.name
+    FROM Person, bounds
+    WHERE LOWER(Person.name) ~>=~ bounds.low AND LOWER(Person.name) ~<~ bounds.high
+    ORDER BY LOWER(Person.name) USING ~<~
+    LIMIT $2
+  )
+) AS candidates
+ORDER BY LOWER(label) = LOWER($1::text) DESC, length(label), label, id
+LIMIT $3
+`
+
+type SuggestParams struct {
+	Prefix         string `json:"prefix"`
+	CandidateLimit int32  `json:"candidate_limit"`
+	SuggestLimit   int32  `json:"suggest_limit"`
 }
 
-func (q *Queries) SearchMovie(ctx context.Context, arg SearchMovieParams) ([]Movie, error) {
-	rows, err := q.db.Query(ctx, searchMovie, arg.ToTsquery, arg.Lower)
+type SuggestRow struct {
+	Kind  string `json:"kind"`
+	ID    int32  `json:"id"`
+	Label string `json:"label"`
+}
+
+// Movie titles and names of people starting with the prefix,
+// people are labeled as actors since actor endpoints serve every person.
+// Every kind takes up to candidate_limit matches in the order of its prefix index,
+// only those are ranked: exact matches go first, then shorter labels.
+// Appending the largest code point gives the upper bound of the prefix range.
+func (q *Queries) Suggest(ctx context.Context, arg SuggestParams) ([]SuggestRow, error) {
+	rows, err := q.db.Query(ctx, suggest, arg.Prefix, arg.CandidateLimit, arg.SuggestLimit)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []Movie
+	var items []SuggestRow
 	for rows.Next() {
-		var i Movie
-		if err := rows.Scan(
-			&i.ID,
-			&i.Title,
-			&i.Description,
-			&i.ReleaseDate,
-			&i.Rating,
-		); err != nil {
+		var i SuggestRow
+		if err := rows.Scan(&i.Kind, &i.ID, &i.Label); err != nil {
 			return nil, err
 		}
 		items = append(items, i)
@@ -394,7 +2785,7 @@ func (q *Queries) SearchMovie(ctx context.Context, arg SearchMovieParams) ([]Mov
 }
 
 const updateActor = `-- name: UpdateActor :exec
-UPDATE Actor
+UPDATE Person
   SET name = COALESCE($2, name),
   birth = COALESCE($3, birth),
   gender = COALESCE($4, gender)
@@ -418,12 +2809,96 @@ func (q *Queries) UpdateActor(ctx context.Context, arg UpdateActorParams) error
 	return err
 }
 
+const updateCollection = `-- name: UpdateCollection :one
+UPDATE Collection
+  SET name = COALESCE($2, name),
+  description = NULLIF(COALESCE($3, description), ''),
+  visibility = COALESCE($4, visibility),
+  updated_at = now()
+WHERE id = $1
+RETURNING id, user_id, name, description, visibility, share_token, created_at, updated_at
+`
+
+type UpdateCollectionParams struct {
+	ID          int32                    `json:"id"`
+	Name        pgtype.Text              `json:"name"`
+	Description pgtype.Text              `json:"description"`
+	Visibility  NullCollectionVisibility `json:"visibility"`
+}
+
+// An empty description removes it
+func (q *Queries) UpdateCollection(ctx context.Context, arg UpdateCollectionParams) (Collection, error) {
+	row := q.db.QueryRow(ctx, updateCollection,
+		arg.ID,
+		arg.Name,
+		arg.Description,
+		arg.Visibility,
+	)
+	var i Collection
+	err := row.Scan(
+		&i.ID,
+		&i.UserID,
+		&i.Name,
+		&i.Description,
+		&i.Visibility,
+		&i.ShareToken,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateCollectionItem = `-- name: UpdateCollectionItem :one
+UPDATE CollectionItem
+SET note = $1
+WHERE collection_id = $2 AND movie_id = $3
+RETURNING collection_id, movie_id, position, note
+`
+
+type UpdateCollectionItemParams struct {
+	Note         pgtype.Text `json:"note"`
+	CollectionID int32       `json:"collection_id"`
+	MovieID      int32       `json:"movie_id"`
+}
+
+func (q *Queries) UpdateCollectionItem(ctx context.Context, arg UpdateCollectionItemParams) (Collectionitem, error) {
+	row := q.db.QueryRow(ctx, updateCollectionItem, arg.Note, arg.CollectionID, arg.MovieID)
+	var i Collectionitem
+	err := row.Scan(
+		&i.CollectionID,
+		&i.MovieID,
+		&i.Position,
+		&i.Note,
+	)
+	return i, err
+}
+
+const updateGenre = `-- name: UpdateGenre :one
+UPDATE Genre
+  SET name = $2
+WHERE id = $1
+RETURNING id, name
+`
+
+type UpdateGenreParams struct {
+	ID   int32  `json:"id"`
+	Name string `json:"name"`
+}
+
+func (q *Queries) UpdateGenre(ctx context.Context, arg UpdateGenreParams) (Genre, error) {
+	row := q.db.QueryRow(ctx, updateGenre, arg.ID, arg.Name)
+	var i Genre
+	err := row.Scan(&i.ID, &i.Name)
+	return i, err
+}
+
 const updateMovie = `-- name: UpdateMovie :exec
 UPDATE Movie
   SET title = COALESCE($2, title),
   description = COALESCE($3, description),
   release_date = COALESCE($4, release_date),
-  rating = COALESCE($5, rating)
+  rating = COALESCE($5, rating),
+  language = COALESCE($6, language)
 WHERE id = $1
 `
 
@@ -433,6 +2908,7 @@ type UpdateMovieParams struct {
 	Description pgtype.Text    `json:"description"`
 	ReleaseDate pgtype.Date    `json:"release_date"`
 	Rating      pgtype.Numeric `json:"rating"`
+	Language    pgtype.Text    `json:"language"`
 }
 
 func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) error {
@@ -442,6 +2918,107 @@ func (q *Queries) UpdateMovie(ctx context.Context, arg UpdateMovieParams) error
 		arg.Description,
 		arg.ReleaseDate,
 		arg.Rating,
+		arg.Language,
 	)
 	return err
 }
+
+const updateReview = `-- name: UpdateReview :one
+UPDATE Review
+  SET rating = COALESCE($2, rating),
+  body = NULLIF(COALESCE($3, body), ''),
+  updated_at = now()
+WHERE id = $1
+RETURNING id, movie_id, user_id, rating, body, created_at, updated_at
+`
+
+type UpdateReviewParams struct {
+	ID     int32       `json:"id"`
+	Rating pgtype.Int4 `json:"rating"`
+	Body   pgtype.Text `json:"body"`
+}
+
+// An empty body removes it
+func (q *Queries) UpdateReview(ctx context.Context, arg UpdateReviewParams) (Review, error) {
+	row := q.db.QueryRow(ctx, updateReview, arg.ID, arg.Rating, arg.Body)
+	var i Review
+	err := row.Scan(
+		&i.ID,
+		&i.MovieID,
+		&i.UserID,
+		&i.Rating,
+		&i.Body,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const updateUserPassword = `-- name: UpdateUserPassword :one
+UPDATE AppUser
+  SET password = $2,
+  tokens_valid_after = $3
+WHERE username = $1
+RETURNING id
+`
+
+type UpdateUserPasswordParams struct {
+	Username         string             `json:"username"`
+	Password         string             `json:"password"`
+	TokensValidAfter pgtype.Timestamptz `json:"tokens_valid_after"`
+}
+
+func (q *Queries) UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (int32, error) {
+	row := q.db.QueryRow(ctx, updateUserPassword, arg.Username, arg.Password, arg.TokensValidAfter)
+	var id int32
+	err := row.Scan(&id)
+	return id, err
+}
+
+const updateUserRole = `-- name: UpdateUserRole :one
+UPDATE AppUser
+  SET role = $2
+WHERE id = $1
+RETURNING id, username, password, role, tokens_valid_after, disabled
+`
+
+type UpdateUserRoleParams struct {
+	ID   int32    `json:"id"`
+	Role UserRole `json:"role"`
+}
+
+func (q *Queries) UpdateUserRole(ctx context.Context, arg UpdateUserRoleParams) (Appuser, error) {
+	row := q.db.QueryRow(ctx, updateUserRole, arg.ID, arg.Role)
+	var i Appuser
+	err := row.Scan(
+		&i.ID,
+		&i.Username,
+		&i.Password,
+		&i.Role,
+		&i.TokensValidAfter,
+		&i.Disabled,
+	)
+	return i, err
+}
+
+const useRefreshToken = `-- name: UseRefreshToken :one
+UPDATE RefreshToken
+  SET used = true
+WHERE id = $1 AND used = false AND revoked = false
+RETURNING id, family_id, user_id, used, revoked, expires_at, created_at
+`
+
+func (q *Queries) UseRefreshToken(ctx context.Context, id string) (Refreshtoken, error) {
+	row := q.db.QueryRow(ctx, useRefreshToken, id)
+	var i Refreshtoken
+	err := row.Scan(
+		&i.ID,
+		&i.FamilyID,
+		&i.UserID,
+		&i.Used,
+		&i.Revoked,
+		&i.ExpiresAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
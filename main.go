@@ -11,7 +11,8 @@ import (
 
 	"github.com/dog4ik/philmotecha/api"
 	"github.com/dog4ik/philmotecha/db"
-	"github.com/jackc/pgx/v5"
+	"github.com/dog4ik/philmotecha/migrations"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/joho/godotenv"
 	"github.com/swaggo/http-swagger"
 )
@@ -29,13 +30,22 @@ func main() {
 		log.Printf("WARN: Error loading .env file\n")
 	}
 
-	ctx := context.Background()
+	run_cli(os.Args[1:])
+}
 
+func lookup_database_url() string {
 	database_url, present := os.LookupEnv("DATABASE_URL")
 
 	if !present {
 		log.Fatalf("DATABASE_URL env variable is not present")
 	}
+	return database_url
+}
+
+func serve() {
+	ctx := context.Background()
+
+	database_url := lookup_database_url()
 
 	env_port, present := os.LookupEnv("PORT")
 	if !present {
@@ -48,51 +58,198 @@ func main() {
 		log.Fatalf("Failed to convert env port to number")
 	}
 
-	conn := retry_db_connection(ctx, database_url)
-	defer conn.Close(ctx)
+	pool_config := db_pool_config(database_url)
+	pool := retry_db_connection(ctx, pool_config)
+	defer pool.Close()
 
-	queries := db.New(conn)
+	if os.Getenv("MIGRATE_ON_START") != "false" {
+		migrate_on_start(ctx, pool)
+	}
+
+	queries := db.New(pool)
+
+	err = queries.DeleteExpiredRevokedTokens(ctx)
+	if err != nil {
+		log.Printf("WARN: Failed to delete expired revoked tokens: %s", err)
+	}
 
-	connection := api.Database{Queries: *queries}
+	connection := api.Database{Queries: *queries, Pool: pool}
 
 	mux := http.NewServeMux()
 
 	authEnsurer := api.NewEnsureAnyAuth(queries)
 	adminAuthEnsurer := api.NewEnsureAdminAuth(queries)
+	collectionReader := api.NewEnsureCollectionReadAccess(queries)
+	collectionWriter := api.NewEnsureCollectionWriteAccess(queries)
 
 	mux.HandleFunc("POST /login", connection.LoginUser)
+	mux.HandleFunc("POST /refresh", connection.RefreshUser)
+	mux.Handle("POST /logout", authEnsurer(connection.Logout))
+	mux.Handle("POST /logout_all", authEnsurer(connection.LogoutAll))
 	mux.Handle("GET /list_actors", authEnsurer(connection.ListActors))
 	mux.Handle("GET /list_movies", authEnsurer(connection.ListMovies))
+	mux.Handle("GET /list_genres", authEnsurer(connection.ListGenres))
+	mux.Handle("GET /actors/{id}", authEnsurer(connection.GetActor))
+	mux.Handle("GET /movies/{id}", authEnsurer(connection.GetMovie))
 	mux.Handle("GET /search", authEnsurer(connection.SearchMovie))
+	mux.Handle("GET /suggest", authEnsurer(connection.Suggest))
 	mux.Handle("POST /add_actor", adminAuthEnsurer(connection.InsertActor))
 	mux.Handle("POST /add_movie", adminAuthEnsurer(connection.InsertMovie))
+	mux.Handle("POST /add_genre", adminAuthEnsurer(connection.InsertGenre))
 	mux.HandleFunc("POST /add_user", connection.InsertUser)
 	mux.Handle("PATCH /update_actor/{id}", adminAuthEnsurer(connection.UpdateActor))
 	mux.Handle("PATCH /update_movie/{id}", adminAuthEnsurer(connection.UpdateMovie))
+	mux.Handle("PATCH /update_genre/{id}", adminAuthEnsurer(connection.UpdateGenre))
 	mux.Handle("DELETE /delete_actor/{id}", adminAuthEnsurer(connection.DeleteActor))
 	mux.Handle("DELETE /delete_movie/{id}", adminAuthEnsurer(connection.DeleteMovie))
+	mux.Handle("DELETE /delete_genre/{id}", adminAuthEnsurer(connection.DeleteGenre))
+	mux.Handle("GET /movie_cast/{id}", authEnsurer(connection.ListMovieCast))
+	mux.Handle("POST /movie_cast/{id}", adminAuthEnsurer(connection.AddMovieCast))
+	mux.Handle("DELETE /movie_cast/{id}", adminAuthEnsurer(connection.RemoveMovieCast))
+	mux.Handle("PUT /movie_cast/{id}", adminAuthEnsurer(connection.ReplaceMovieCast))
+	mux.Handle("GET /movie_crew/{id}", authEnsurer(connection.ListMovieCrew))
+	mux.Handle("POST /movie_crew/{id}", adminAuthEnsurer(connection.AddMovieCrew))
+	mux.Handle("DELETE /movie_crew/{id}", adminAuthEnsurer(connection.RemoveMovieCrew))
+	mux.Handle("PUT /movie_crew/{id}", adminAuthEnsurer(connection.ReplaceMovieCrew))
+	mux.Handle("GET /movie_reviews/{id}", authEnsurer(connection.ListMovieReviews))
+	mux.Handle("POST /movie_reviews/{id}", authEnsurer(connection.InsertReview))
+	mux.Handle("PATCH /update_review/{id}", authEnsurer(connection.UpdateReview))
+	mux.Handle("DELETE /delete_review/{id}", authEnsurer(connection.DeleteReview))
+	mux.Handle("GET /watchlist", authEnsurer(connection.ListWatchlist))
+	mux.Handle("POST /watchlist/{id}", authEnsurer(connection.AddToWatchlist))
+	mux.Handle("DELETE /watchlist/{id}", authEnsurer(connection.RemoveFromWatchlist))
+	mux.Handle("GET /watched", authEnsurer(connection.ListWatched))
+	mux.Handle("POST /watched/{id}", authEnsurer(connection.LogWatched))
+	mux.Handle("DELETE /watched/{id}", authEnsurer(connection.RemoveWatched))
+	mux.Handle("GET /collections", authEnsurer(connection.ListCollections))
+	mux.Handle("POST /collections", authEnsurer(connection.InsertCollection))
+	mux.Handle("GET /collections/{id}", collectionReader(connection.GetCollection))
+	mux.Handle("PATCH /collections/{id}", collectionWriter(connection.UpdateCollection))
+	mux.Handle("DELETE /collections/{id}", collectionWriter(connection.DeleteCollection))
+	mux.Handle("POST /collections/{id}/share_token", collectionWriter(connection.ResetCollectionShareToken))
+	mux.Handle("POST /collections/{id}/items", collectionWriter(connection.AddCollectionItem))
+	mux.Handle("PATCH /collections/{id}/items/{movie_id}", collectionWriter(connection.UpdateCollectionItem))
+	mux.Handle("DELETE /collections/{id}/items/{movie_id}", collectionWriter(connection.DeleteCollectionItem))
+	mux.Handle("PUT /collections/{id}/order", collectionWriter(connection.ReorderCollection))
+	mux.HandleFunc("GET /shared_collections/{token}", connection.GetSharedCollection)
+	mux.Handle("POST /actor_movies/{id}", adminAuthEnsurer(connection.AddActorMovies))
+	mux.Handle("DELETE /actor_movies/{id}", adminAuthEnsurer(connection.RemoveActorMovies))
+	mux.Handle("GET /list_users", adminAuthEnsurer(connection.ListUsers))
+	mux.Handle("PATCH /update_user_role/{id}", adminAuthEnsurer(connection.UpdateUserRole))
+	mux.Handle("PATCH /disable_user/{id}", adminAuthEnsurer(connection.DisableUser))
+	mux.Handle("PATCH /enable_user/{id}", adminAuthEnsurer(connection.EnableUser))
+	mux.Handle("DELETE /delete_user/{id}", adminAuthEnsurer(connection.DeleteUser))
+	mux.Handle("GET /pool_stats", adminAuthEnsurer(connection.PoolStats))
+	mux.Handle("POST /maintenance", adminAuthEnsurer(connection.RunMaintenance))
+	mux.Handle("GET /maintenance", adminAuthEnsurer(connection.ListMaintenanceRuns))
+	mux.Handle("GET /maintenance/{id}", adminAuthEnsurer(connection.GetMaintenanceRun))
 	mux.HandleFunc("DELETE /clear_db", connection.ClearDb)
 	mux.HandleFunc("GET /swagger/doc.json", swagger_config)
 	mux.HandleFunc("GET /swagger/*", httpSwagger.Handler(httpSwagger.URL("http://localhost:6969/swagger/doc.json")))
 
+	schedule_maintenance(ctx, &connection)
+
 	log.Printf("Started Listening on port %d", port)
 
 	err = http.ListenAndServe(fmt.Sprintf("0.0.0.0:%d", port), mux)
 	log.Fatalf("Failed to listen and serve")
 }
 
-func retry_db_connection(ctx context.Context, database_url string) *pgx.Conn {
+// db_pool_config parses DATABASE_URL and applies pool settings from the environment.
+// Settings passed as pool_* parameters of the url are kept unless overridden.
+func db_pool_config(database_url string) *pgxpool.Config {
+	config, err := pgxpool.ParseConfig(database_url)
+	if err != nil {
+		log.Fatalf("Failed to parse DATABASE_URL: %s", err)
+	}
+
+	if value, present := os.LookupEnv("DB_MAX_CONNS"); present {
+		max_conns, err := strconv.Atoi(value)
+		if err != nil || max_conns < 1 {
+			log.Fatalf("DB_MAX_CONNS must be a positive number")
+		}
+		config.MaxConns = int32(max_conns)
+	}
+	if value, present := os.LookupEnv("DB_MIN_CONNS"); present {
+		min_conns, err := strconv.Atoi(value)
+		if err != nil || min_conns < 0 {
+			log.Fatalf("DB_MIN_CONNS must be a non negative number")
+		}
+		config.MinConns = int32(min_conns)
+	}
+	if config.MinConns > config.MaxConns {
+		log.Fatalf("DB_MIN_CONNS(%d) is greater than DB_MAX_CONNS(%d)", config.MinConns, config.MaxConns)
+	}
+	if value, present := os.LookupEnv("DB_HEALTH_CHECK_PERIOD"); present {
+		period, err := time.ParseDuration(value)
+		if err != nil || period <= 0 {
+			log.Fatalf("DB_HEALTH_CHECK_PERIOD must be a positive duration like 30s")
+		}
+		config.HealthCheckPeriod = period
+	}
+	if value, present := os.LookupEnv("DB_CONNECT_TIMEOUT"); present {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			log.Fatalf("DB_CONNECT_TIMEOUT must be a positive duration like 5s")
+		}
+		config.ConnConfig.ConnectTimeout = timeout
+	}
+	return config
+}
+
+func retry_db_connection(ctx context.Context, config *pgxpool.Config) *pgxpool.Pool {
 	for {
-		conn, err := pgx.Connect(ctx, database_url)
-		if err != nil {
-			log.Printf("failed to connect to the database: %s RETRYING", err)
-			time.Sleep(1 * time.Second)
-		} else {
-			return conn
+		pool, err := pgxpool.NewWithConfig(ctx, config)
+		if err == nil {
+			err = pool.Ping(ctx)
+			if err == nil {
+				return pool
+			}
+			pool.Close()
 		}
+		log.Printf("failed to connect to the database: %s RETRYING", err)
+		time.Sleep(1 * time.Second)
 	}
 }
 
+func migrate_on_start(ctx context.Context, pool *pgxpool.Pool) {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		log.Fatalf("Failed to acquire connection for migrations: %s", err)
+	}
+	defer conn.Release()
+
+	_, err = migrations.Up(ctx, conn.Conn())
+	if err != nil {
+		log.Fatalf("Failed to apply migrations: %s", err)
+	}
+}
+
+// schedule_maintenance starts MAINTENANCE_TASK every MAINTENANCE_INTERVAL when the interval is set
+func schedule_maintenance(ctx context.Context, connection *api.Database) {
+	value, present := os.LookupEnv("MAINTENANCE_INTERVAL")
+	if !present {
+		return
+	}
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Fatalf("MAINTENANCE_INTERVAL must be a positive duration like 24h")
+	}
+
+	task := db.MaintenanceTaskAll
+	if value, present := os.LookupEnv("MAINTENANCE_TASK"); present {
+		task = db.MaintenanceTask(value)
+	}
+	switch task {
+	case db.MaintenanceTaskVacuum, db.MaintenanceTaskAnalyze, db.MaintenanceTaskReindex, db.MaintenanceTaskAll:
+	default:
+		log.Fatalf("MAINTENANCE_TASK must be one of vacuum, analyze, reindex or all")
+	}
+
+	log.Printf("Scheduled %s maintenance every %s", task, interval)
+	go connection.ScheduleMaintenance(ctx, interval, task)
+}
+
 func swagger_config(w http.ResponseWriter, r *http.Request) {
 	config, err := os.ReadFile("docs/swagger.json")
 	if err != nil {
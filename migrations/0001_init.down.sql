@@ -0,0 +1,10 @@
+DROP TRIGGER movie_table_trigger ON Movie;
+DROP FUNCTION reindex_movies();
+
+DROP TABLE MovieActor;
+DROP TABLE Movie;
+DROP TABLE Actor;
+DROP TABLE AppUser;
+
+DROP TYPE user_role;
+DROP TYPE gender_type;
@@ -0,0 +1,13 @@
+-- Refresh tokens of a login share a family, reuse of a used token revokes the whole family
+CREATE TABLE RefreshToken (
+    id VARCHAR(64) PRIMARY KEY,
+    family_id VARCHAR(64) NOT NULL,
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    used BOOLEAN NOT NULL DEFAULT false,
+    revoked BOOLEAN NOT NULL DEFAULT false,
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX refresh_token_family_idx
+ON RefreshToken (family_id);
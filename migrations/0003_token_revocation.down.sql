@@ -0,0 +1,3 @@
+DROP TABLE RevokedToken;
+
+ALTER TABLE AppUser DROP COLUMN tokens_valid_after;
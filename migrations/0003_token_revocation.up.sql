@@ -0,0 +1,9 @@
+-- Tokens issued before tokens_valid_after are rejected, single access tokens are revoked by their id
+ALTER TABLE AppUser ADD COLUMN tokens_valid_after TIMESTAMPTZ NOT NULL DEFAULT now();
+
+CREATE TABLE RevokedToken (
+    jti VARCHAR(64) PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
@@ -0,0 +1 @@
+ALTER TABLE AppUser DROP COLUMN disabled;
@@ -0,0 +1,2 @@
+-- Disabled users can not log in or use their tokens
+ALTER TABLE AppUser ADD COLUMN disabled BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,10 @@
+DROP TRIGGER movie_search_actor ON Actor;
+DROP TRIGGER movie_search_cast ON MovieActor;
+DROP TRIGGER movie_search_movie ON Movie;
+
+DROP FUNCTION movie_search_actor_trigger();
+DROP FUNCTION movie_search_cast_trigger();
+DROP FUNCTION movie_search_movie_trigger();
+DROP FUNCTION refresh_movie_search(INT);
+
+DROP TABLE MovieSearch;
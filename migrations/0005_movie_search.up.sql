@@ -0,0 +1,90 @@
+-- Search document of every movie.
+-- Cast names live in another table so they can not be a part of a generated column on Movie,
+-- triggers below keep this table in sync instead.
+CREATE TABLE MovieSearch (
+    movie_id INT PRIMARY KEY REFERENCES Movie(id) ON DELETE CASCADE,
+    title TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    cast_names TEXT NOT NULL DEFAULT '',
+    document TSVECTOR NOT NULL GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', title), 'A') ||
+        setweight(to_tsvector('english', description), 'B') ||
+        setweight(to_tsvector('english', cast_names), 'C')
+    ) STORED
+);
+
+CREATE INDEX movie_search_document_idx
+ON MovieSearch
+USING GIN (document);
+
+CREATE FUNCTION refresh_movie_search(target INT)
+RETURNS VOID AS $$
+    INSERT INTO MovieSearch (movie_id, title, description, cast_names)
+    SELECT
+        Movie.id,
+        Movie.title,
+        COALESCE(Movie.description, ''),
+        COALESCE((
+            SELECT string_agg(Actor.name, ' ' ORDER BY Actor.name)
+            FROM MovieActor
+            JOIN Actor ON Actor.id = MovieActor.actor_id
+            WHERE MovieActor.movie_id = Movie.id
+        ), '')
+    FROM Movie
+    WHERE Movie.id = target
+    ON CONFLICT (movie_id) DO UPDATE SET
+        title = EXCLUDED.title,
+        description = EXCLUDED.description,
+        cast_names = EXCLUDED.cast_names;
+$$ LANGUAGE sql;
+
+CREATE FUNCTION movie_search_movie_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_movie_search(NEW.id);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION movie_search_cast_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        PERFORM refresh_movie_search(OLD.movie_id);
+    ELSE
+        PERFORM refresh_movie_search(NEW.movie_id);
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION movie_search_actor_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_movie_search(MovieActor.movie_id)
+    FROM MovieActor
+    WHERE MovieActor.actor_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER movie_search_movie
+AFTER INSERT OR UPDATE OF title, description
+ON Movie
+FOR EACH ROW
+EXECUTE FUNCTION movie_search_movie_trigger();
+
+CREATE TRIGGER movie_search_cast
+AFTER INSERT OR DELETE
+ON MovieActor
+FOR EACH ROW
+EXECUTE FUNCTION movie_search_cast_trigger();
+
+CREATE TRIGGER movie_search_actor
+AFTER UPDATE OF name
+ON Actor
+FOR EACH ROW
+WHEN (OLD.name IS DISTINCT FROM NEW.name)
+EXECUTE FUNCTION movie_search_actor_trigger();
+
+SELECT refresh_movie_search(id) FROM Movie;
@@ -0,0 +1,4 @@
+DROP INDEX actor_name_trgm_idx;
+DROP INDEX movie_title_trgm_idx;
+
+DROP EXTENSION pg_trgm;
@@ -0,0 +1,9 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX movie_title_trgm_idx
+ON Movie
+USING GIN (title gin_trgm_ops);
+
+CREATE INDEX actor_name_trgm_idx
+ON Actor
+USING GIN (name gin_trgm_ops);
@@ -0,0 +1,2 @@
+DROP INDEX actor_name_prefix_idx;
+DROP INDEX movie_title_prefix_idx;
@@ -0,0 +1,7 @@
+-- text_pattern_ops compares bytes, so prefix ranges can be answered from the index
+-- regardless of the database collation
+CREATE INDEX movie_title_prefix_idx
+ON Movie (LOWER(title) text_pattern_ops);
+
+CREATE INDEX actor_name_prefix_idx
+ON Actor (LOWER(name) text_pattern_ops);
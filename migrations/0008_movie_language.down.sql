@@ -0,0 +1,44 @@
+DROP TRIGGER movie_search_movie ON Movie;
+CREATE TRIGGER movie_search_movie
+AFTER INSERT OR UPDATE OF title, description
+ON Movie
+FOR EACH ROW
+EXECUTE FUNCTION movie_search_movie_trigger();
+
+CREATE OR REPLACE FUNCTION refresh_movie_search(target INT)
+RETURNS VOID AS $$
+    INSERT INTO MovieSearch (movie_id, title, description, cast_names)
+    SELECT
+        Movie.id,
+        Movie.title,
+        COALESCE(Movie.description, ''),
+        COALESCE((
+            SELECT string_agg(Actor.name, ' ' ORDER BY Actor.name)
+            FROM MovieActor
+            JOIN Actor ON Actor.id = MovieActor.actor_id
+            WHERE MovieActor.movie_id = Movie.id
+        ), '')
+    FROM Movie
+    WHERE Movie.id = target
+    ON CONFLICT (movie_id) DO UPDATE SET
+        title = EXCLUDED.title,
+        description = EXCLUDED.description,
+        cast_names = EXCLUDED.cast_names;
+$$ LANGUAGE sql;
+
+DROP INDEX movie_search_document_idx;
+ALTER TABLE MovieSearch DROP COLUMN document;
+ALTER TABLE MovieSearch DROP COLUMN config;
+ALTER TABLE MovieSearch ADD COLUMN document TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector('english', title), 'A') ||
+    setweight(to_tsvector('english', description), 'B') ||
+    setweight(to_tsvector('english', cast_names), 'C')
+) STORED;
+
+CREATE INDEX movie_search_document_idx
+ON MovieSearch
+USING GIN (document);
+
+DROP FUNCTION search_config(TEXT);
+
+ALTER TABLE Movie DROP COLUMN language;
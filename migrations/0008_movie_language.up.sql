@@ -0,0 +1,56 @@
+-- ISO 639-1 code of the movie language
+ALTER TABLE Movie ADD COLUMN language VARCHAR(8) NOT NULL DEFAULT 'en';
+
+-- Text search configuration of a language, languages without a stemmer use the simple configuration
+CREATE FUNCTION search_config(language TEXT)
+RETURNS regconfig AS $$
+    SELECT CASE language
+        WHEN 'en' THEN 'english'::regconfig
+        WHEN 'ru' THEN 'russian'::regconfig
+        ELSE 'simple'::regconfig
+    END;
+$$ LANGUAGE sql IMMUTABLE;
+
+-- Generated columns can not be altered, the document is recreated with a per movie configuration
+DROP INDEX movie_search_document_idx;
+ALTER TABLE MovieSearch DROP COLUMN document;
+ALTER TABLE MovieSearch ADD COLUMN config regconfig NOT NULL DEFAULT 'english';
+ALTER TABLE MovieSearch ADD COLUMN document TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector(config, title), 'A') ||
+    setweight(to_tsvector(config, description), 'B') ||
+    setweight(to_tsvector(config, cast_names), 'C')
+) STORED;
+
+CREATE INDEX movie_search_document_idx
+ON MovieSearch
+USING GIN (document);
+
+CREATE OR REPLACE FUNCTION refresh_movie_search(target INT)
+RETURNS VOID AS $$
+    INSERT INTO MovieSearch (movie_id, title, description, cast_names, config)
+    SELECT
+        Movie.id,
+        Movie.title,
+        COALESCE(Movie.description, ''),
+        COALESCE((
+            SELECT string_agg(Actor.name, ' ' ORDER BY Actor.name)
+            FROM MovieActor
+            JOIN Actor ON Actor.id = MovieActor.actor_id
+            WHERE MovieActor.movie_id = Movie.id
+        ), ''),
+        search_config(Movie.language)
+    FROM Movie
+    WHERE Movie.id = target
+    ON CONFLICT (movie_id) DO UPDATE SET
+        title = EXCLUDED.title,
+        description = EXCLUDED.description,
+        cast_names = EXCLUDED.cast_names,
+        config = EXCLUDED.config;
+$$ LANGUAGE sql;
+
+DROP TRIGGER movie_search_movie ON Movie;
+CREATE TRIGGER movie_search_movie
+AFTER INSERT OR UPDATE OF title, description, language
+ON Movie
+FOR EACH ROW
+EXECUTE FUNCTION movie_search_movie_trigger();
@@ -0,0 +1,10 @@
+DROP TABLE MaintenanceRun;
+
+DROP TYPE maintenance_status;
+DROP TYPE maintenance_task;
+
+CREATE INDEX title_idx
+ON Movie
+USING GIN ((to_tsvector('english',title)));
+
+-- movie_table_trigger is not restored, it made every write to Movie fail
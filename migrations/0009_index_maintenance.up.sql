@@ -0,0 +1,21 @@
+-- REINDEX CONCURRENTLY can not run inside the transaction of a trigger, so every write to Movie failed.
+-- GIN indexes are maintained on write, bloat is handled by the maintenance job instead.
+DROP TRIGGER movie_table_trigger ON Movie;
+DROP FUNCTION reindex_movies();
+
+-- Search goes through MovieSearch, the index is not used anymore
+DROP INDEX title_idx;
+
+CREATE TYPE maintenance_task AS ENUM ('vacuum', 'analyze', 'reindex', 'all');
+CREATE TYPE maintenance_status AS ENUM ('running', 'succeeded', 'failed');
+
+CREATE TABLE MaintenanceRun (
+    id SERIAL PRIMARY KEY,
+    task maintenance_task NOT NULL,
+    status maintenance_status NOT NULL DEFAULT 'running',
+    -- null when started by the schedule
+    triggered_by INT REFERENCES AppUser(id) ON DELETE SET NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    error TEXT
+);
@@ -0,0 +1,2 @@
+DROP TABLE MovieGenre;
+DROP TABLE Genre;
@@ -0,0 +1,16 @@
+CREATE TABLE Genre (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(50) NOT NULL
+);
+
+CREATE UNIQUE INDEX genre_name_idx
+ON Genre (LOWER(name));
+
+CREATE TABLE MovieGenre (
+    movie_id INT REFERENCES Movie(id) ON DELETE CASCADE,
+    genre_id INT REFERENCES Genre(id) ON DELETE CASCADE,
+    PRIMARY KEY (movie_id, genre_id)
+);
+
+CREATE INDEX movie_genre_genre_idx
+ON MovieGenre (genre_id);
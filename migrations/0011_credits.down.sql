@@ -0,0 +1,63 @@
+CREATE OR REPLACE FUNCTION movie_search_actor_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_movie_search(MovieActor.movie_id)
+    FROM MovieActor
+    WHERE MovieActor.actor_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION refresh_movie_search(target INT)
+RETURNS VOID AS $$
+    INSERT INTO MovieSearch (movie_id, title, description, cast_names, config)
+    SELECT
+        Movie.id,
+        Movie.title,
+        COALESCE(Movie.description, ''),
+        COALESCE((
+            SELECT string_agg(Actor.name, ' ' ORDER BY Actor.name)
+            FROM MovieActor
+            JOIN Actor ON Actor.id = MovieActor.actor_id
+            WHERE MovieActor.movie_id = Movie.id
+        ), ''),
+        search_config(Movie.language)
+    FROM Movie
+    WHERE Movie.id = target
+    ON CONFLICT (movie_id) DO UPDATE SET
+        title = EXCLUDED.title,
+        description = EXCLUDED.description,
+        cast_names = EXCLUDED.cast_names,
+        config = EXCLUDED.config;
+$$ LANGUAGE sql;
+
+DROP INDEX movie_search_document_idx;
+ALTER TABLE MovieSearch DROP COLUMN document;
+ALTER TABLE MovieSearch DROP COLUMN crew_names;
+ALTER TABLE MovieSearch ADD COLUMN document TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector(config, title), 'A') ||
+    setweight(to_tsvector(config, description), 'B') ||
+    setweight(to_tsvector(config, cast_names), 'C')
+) STORED;
+
+CREATE INDEX movie_search_document_idx
+ON MovieSearch
+USING GIN (document);
+
+-- Crew credits have no place in the old schema
+DROP INDEX credit_person_idx;
+DELETE FROM Credit WHERE role <> 'actor';
+ALTER TABLE Credit DROP CONSTRAINT credit_pkey;
+ALTER TABLE Credit DROP COLUMN role;
+ALTER TABLE Credit ADD PRIMARY KEY (movie_id, person_id);
+ALTER TABLE Credit RENAME COLUMN person_id TO actor_id;
+ALTER TABLE Credit RENAME TO MovieActor;
+ALTER INDEX credit_pkey RENAME TO movieactor_pkey;
+
+ALTER INDEX person_name_prefix_idx RENAME TO actor_name_prefix_idx;
+ALTER INDEX person_name_trgm_idx RENAME TO actor_name_trgm_idx;
+ALTER TABLE Person RENAME TO Actor;
+
+DROP TYPE credit_role;
+
+SELECT refresh_movie_search(id) FROM Movie;
@@ -0,0 +1,76 @@
+CREATE TYPE credit_role AS ENUM ('actor', 'director', 'writer', 'producer', 'composer');
+
+-- Actors become people who take part in movies through credits,
+-- a person can have several roles in the same movie
+ALTER TABLE Actor RENAME TO Person;
+ALTER INDEX actor_name_trgm_idx RENAME TO person_name_trgm_idx;
+ALTER INDEX actor_name_prefix_idx RENAME TO person_name_prefix_idx;
+
+ALTER TABLE MovieActor RENAME TO Credit;
+ALTER TABLE Credit RENAME COLUMN actor_id TO person_id;
+ALTER TABLE Credit ADD COLUMN role credit_role NOT NULL DEFAULT 'actor';
+ALTER TABLE Credit ALTER COLUMN role DROP DEFAULT;
+ALTER TABLE Credit DROP CONSTRAINT movieactor_pkey;
+ALTER TABLE Credit ADD PRIMARY KEY (movie_id, person_id, role);
+
+CREATE INDEX credit_person_idx
+ON Credit (person_id, role);
+
+-- Cast names keep their weight, names of the crew are matched with the lowest one
+DROP INDEX movie_search_document_idx;
+ALTER TABLE MovieSearch DROP COLUMN document;
+ALTER TABLE MovieSearch ADD COLUMN crew_names TEXT NOT NULL DEFAULT '';
+ALTER TABLE MovieSearch ADD COLUMN document TSVECTOR NOT NULL GENERATED ALWAYS AS (
+    setweight(to_tsvector(config, title), 'A') ||
+    setweight(to_tsvector(config, description), 'B') ||
+    setweight(to_tsvector(config, cast_names), 'C') ||
+    setweight(to_tsvector(config, crew_names), 'D')
+) STORED;
+
+CREATE INDEX movie_search_document_idx
+ON MovieSearch
+USING GIN (document);
+
+CREATE OR REPLACE FUNCTION refresh_movie_search(target INT)
+RETURNS VOID AS $$
+    INSERT INTO MovieSearch (movie_id, title, description, cast_names, crew_names, config)
+    SELECT
+        Movie.id,
+        Movie.title,
+        COALESCE(Movie.description, ''),
+        COALESCE((
+            SELECT string_agg(Person.name, ' ' ORDER BY Person.name)
+            FROM Credit
+            JOIN Person ON Person.id = Credit.person_id
+            WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+        ), ''),
+        COALESCE((
+            SELECT string_agg(DISTINCT Person.name, ' ' ORDER BY Person.name)
+            FROM Credit
+            JOIN Person ON Person.id = Credit.person_id
+            WHERE Credit.movie_id = Movie.id AND Credit.role <> 'actor'
+        ), ''),
+        search_config(Movie.language)
+    FROM Movie
+    WHERE Movie.id = target
+    ON CONFLICT (movie_id) DO UPDATE SET
+        title = EXCLUDED.title,
+        description = EXCLUDED.description,
+        cast_names = EXCLUDED.cast_names,
+        crew_names = EXCLUDED.crew_names,
+        config = EXCLUDED.config;
+$$ LANGUAGE sql;
+
+-- Renamed tables keep their triggers, only the function that reads them by name is replaced
+CREATE OR REPLACE FUNCTION movie_search_actor_trigger()
+RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM refresh_movie_search(Credit.movie_id)
+    FROM Credit
+    WHERE Credit.person_id = NEW.id
+    GROUP BY Credit.movie_id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+SELECT refresh_movie_search(id) FROM Movie;
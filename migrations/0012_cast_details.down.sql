@@ -0,0 +1,3 @@
+ALTER TABLE Credit DROP COLUMN uncredited;
+ALTER TABLE Credit DROP COLUMN billing_order;
+ALTER TABLE Credit DROP COLUMN characters;
@@ -0,0 +1,4 @@
+-- Details of acting credits, crew credits keep the defaults
+ALTER TABLE Credit ADD COLUMN characters TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE Credit ADD COLUMN billing_order INT CHECK (billing_order > 0);
+ALTER TABLE Credit ADD COLUMN uncredited BOOLEAN NOT NULL DEFAULT false;
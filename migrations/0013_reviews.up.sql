@@ -0,0 +1,17 @@
+-- Ratings and reviews of regular users, a user reviews a movie once
+CREATE TABLE Review (
+    id SERIAL PRIMARY KEY,
+    movie_id INT NOT NULL REFERENCES Movie(id) ON DELETE CASCADE,
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    rating INT NOT NULL CHECK (rating >= 0 AND rating <= 10),
+    body TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    UNIQUE (movie_id, user_id)
+);
+
+CREATE INDEX review_movie_idx
+ON Review (movie_id, id);
+
+CREATE INDEX review_user_idx
+ON Review (user_id);
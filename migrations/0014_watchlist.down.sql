@@ -0,0 +1,2 @@
+DROP TABLE Watched;
+DROP TABLE Watchlist;
@@ -0,0 +1,22 @@
+-- Movies a user wants to see
+CREATE TABLE Watchlist (
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    movie_id INT NOT NULL REFERENCES Movie(id) ON DELETE CASCADE,
+    added_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, movie_id)
+);
+
+-- Movies a user has seen, watched_on is the date of the latest watch
+CREATE TABLE Watched (
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    movie_id INT NOT NULL REFERENCES Movie(id) ON DELETE CASCADE,
+    watched_on DATE NOT NULL DEFAULT CURRENT_DATE,
+    rewatch_count INT NOT NULL DEFAULT 0 CHECK (rewatch_count >= 0),
+    PRIMARY KEY (user_id, movie_id)
+);
+
+CREATE INDEX watchlist_movie_idx
+ON Watchlist (movie_id);
+
+CREATE INDEX watched_movie_idx
+ON Watched (movie_id);
@@ -0,0 +1,3 @@
+DROP TABLE CollectionItem;
+DROP TABLE Collection;
+DROP TYPE collection_visibility;
@@ -0,0 +1,29 @@
+CREATE TYPE collection_visibility AS ENUM ('private', 'unlisted', 'public');
+
+-- Ordered movie lists of users. Unlisted collections are readable by anyone who knows the share token.
+CREATE TABLE Collection (
+    id SERIAL PRIMARY KEY,
+    user_id INT NOT NULL REFERENCES AppUser(id) ON DELETE CASCADE,
+    name VARCHAR(200) NOT NULL,
+    description TEXT,
+    visibility collection_visibility NOT NULL DEFAULT 'private',
+    share_token TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX collection_user_idx
+ON Collection (user_id, id);
+
+-- Positions are checked at commit so items can swap places in one statement
+CREATE TABLE CollectionItem (
+    collection_id INT NOT NULL REFERENCES Collection(id) ON DELETE CASCADE,
+    movie_id INT NOT NULL REFERENCES Movie(id) ON DELETE CASCADE,
+    position INT NOT NULL,
+    note TEXT,
+    PRIMARY KEY (collection_id, movie_id),
+    UNIQUE (collection_id, position) DEFERRABLE INITIALLY DEFERRED
+);
+
+CREATE INDEX collectionitem_movie_idx
+ON CollectionItem (movie_id);
@@ -0,0 +1 @@
+DROP FUNCTION filter_movies(DATE, DATE, NUMERIC, NUMERIC, BOOLEAN, TEXT, INT[], INT[], INT[]);
@@ -0,0 +1,44 @@
+-- Ids of movies that pass the filters of the movie listing, a null filter is skipped.
+-- The listing and its genre counts both select from it so their filters can not drift apart.
+-- A plain sql function is inlined into the calling query by the planner.
+CREATE FUNCTION filter_movies(
+    released_after DATE,
+    released_before DATE,
+    min_rating NUMERIC,
+    max_rating NUMERIC,
+    has_description BOOLEAN,
+    title_prefix TEXT,
+    actor_ids INT[],
+    director_ids INT[],
+    genre_ids INT[]
+)
+RETURNS SETOF INT AS $$
+    SELECT Movie.id
+    FROM Movie
+    WHERE (released_after IS NULL OR Movie.release_date >= released_after)
+        AND (released_before IS NULL OR Movie.release_date <= released_before)
+        AND (min_rating IS NULL OR Movie.rating >= min_rating)
+        AND (max_rating IS NULL OR Movie.rating <= max_rating)
+        AND (has_description IS NULL
+            OR (COALESCE(Movie.description, '') <> '') = has_description)
+        AND (title_prefix IS NULL
+            OR starts_with(LOWER(Movie.title), LOWER(title_prefix)))
+        AND (actor_ids IS NULL OR (
+            SELECT COUNT(*)
+            FROM Credit
+            WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+                AND Credit.person_id = ANY(actor_ids)
+        ) = CARDINALITY(actor_ids))
+        AND (director_ids IS NULL OR (
+            SELECT COUNT(*)
+            FROM Credit
+            WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+                AND Credit.person_id = ANY(director_ids)
+        ) = CARDINALITY(director_ids))
+        AND (genre_ids IS NULL OR (
+            SELECT COUNT(*)
+            FROM MovieGenre
+            WHERE MovieGenre.movie_id = Movie.id
+                AND MovieGenre.genre_id = ANY(genre_ids)
+        ) = CARDINALITY(genre_ids));
+$$ LANGUAGE sql STABLE;
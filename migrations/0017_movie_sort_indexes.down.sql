@@ -0,0 +1,6 @@
+DROP INDEX movie_title_desc_idx;
+DROP INDEX movie_title_idx;
+DROP INDEX movie_release_date_desc_idx;
+DROP INDEX movie_release_date_idx;
+DROP INDEX movie_rating_desc_idx;
+DROP INDEX movie_rating_idx;
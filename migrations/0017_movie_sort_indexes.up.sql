@@ -0,0 +1,19 @@
+-- Indexes in the orders of the single key movie listing, ties are broken by ascending id in both directions.
+-- Movies with an empty key are read from the same indexes.
+CREATE INDEX movie_rating_idx
+ON Movie (rating, id);
+
+CREATE INDEX movie_rating_desc_idx
+ON Movie (rating DESC, id);
+
+CREATE INDEX movie_release_date_idx
+ON Movie (release_date, id);
+
+CREATE INDEX movie_release_date_desc_idx
+ON Movie (release_date DESC, id);
+
+CREATE INDEX movie_title_idx
+ON Movie (title, id);
+
+CREATE INDEX movie_title_desc_idx
+ON Movie (title DESC, id);
@@ -0,0 +1,285 @@
+// Package migrations contains the versioned database schema and the runner that applies it.
+//
+// Every migration is a pair of files named NNNN_description.up.sql and NNNN_description.down.sql.
+// Applied versions are recorded in the schema_migrations table.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+//go:embed *.sql
+var files embed.FS
+
+// Arbitrary key of the advisory lock that serializes concurrent runners
+const lockKey int64 = 7_092_214_563
+
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+type Status struct {
+	Version   int64
+	Name      string
+	AppliedAt *time.Time
+}
+
+// Load returns all embedded migrations ordered by version
+func Load() ([]Migration, error) {
+	entries, err := fs.ReadDir(files, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	by_version := make(map[int64]*Migration)
+	for _, entry := range entries {
+		file_name := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(file_name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(file_name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+
+		base := strings.TrimSuffix(file_name, "."+direction+".sql")
+		version_part, name, found := strings.Cut(base, "_")
+		if !found {
+			return nil, fmt.Errorf("migration %s is not named as NNNN_description", file_name)
+		}
+		version, err := strconv.ParseInt(version_part, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("migration %s has invalid version: %w", file_name, err)
+		}
+
+		content, err := files.ReadFile(file_name)
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := by_version[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			by_version[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has different names: %s and %s", version, migration.Name, name)
+		}
+		if direction == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	var out []Migration
+	for _, migration := range by_version {
+		if migration.Up == "" {
+			return nil, fmt.Errorf("migration %d has no up file", migration.Version)
+		}
+		out = append(out, *migration)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Version < out[j].Version })
+	return out, nil
+}
+
+func lock(ctx context.Context, conn *pgx.Conn) (func(), error) {
+	_, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	return func() {
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+		if err != nil {
+			log.Printf("WARN: Failed to release migration lock: %s", err)
+		}
+	}, nil
+}
+
+func ensureTable(ctx context.Context, conn *pgx.Conn) error {
+	_, err := conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    name TEXT NOT NULL,
+    applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+)`)
+	return err
+}
+
+func applied(ctx context.Context, conn *pgx.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var applied_at time.Time
+		if err := rows.Scan(&version, &applied_at); err != nil {
+			return nil, err
+		}
+		out[version] = applied_at
+	}
+	return out, rows.Err()
+}
+
+// baseline marks the initial migration as applied for databases
+// that were created from schema.sql before migrations existed.
+// The initial migration is exactly that schema, later changes live in their own migrations.
+func baseline(ctx context.Context, conn *pgx.Conn, done map[int64]time.Time, all []Migration) error {
+	if len(done) != 0 || len(all) == 0 {
+		return nil
+	}
+	var exists bool
+	err := conn.QueryRow(ctx, "SELECT to_regclass('appuser') IS NOT NULL").Scan(&exists)
+	if err != nil || !exists {
+		return err
+	}
+	first := all[0]
+	_, err = conn.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", first.Version, first.Name)
+	if err != nil {
+		return err
+	}
+	log.Printf("Existing schema detected, marked migration %04d_%s as applied", first.Version, first.Name)
+	done[first.Version] = time.Now()
+	return nil
+}
+
+func run(ctx context.Context, conn *pgx.Conn, migration Migration, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if up {
+		_, err = tx.Exec(ctx, migration.Up)
+		if err == nil {
+			_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+		}
+	} else {
+		_, err = tx.Exec(ctx, migration.Down)
+		if err == nil {
+			_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Up applies all pending migrations and returns the applied ones
+func Up(ctx context.Context, conn *pgx.Conn) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lock(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	done, err := applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+	if err := baseline(ctx, conn, done, all); err != nil {
+		return nil, fmt.Errorf("failed to baseline existing schema: %w", err)
+	}
+
+	var out []Migration
+	for _, migration := range all {
+		if _, ok := done[migration.Version]; ok {
+			continue
+		}
+		if err := run(ctx, conn, migration, true); err != nil {
+			return out, fmt.Errorf("migration %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Applied migration %04d_%s", migration.Version, migration.Name)
+		out = append(out, migration)
+	}
+	return out, nil
+}
+
+// Down reverts up to steps most recent migrations and returns the reverted ones
+func Down(ctx context.Context, conn *pgx.Conn, steps int) ([]Migration, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	unlock, err := lock(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	done, err := applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	var out []Migration
+	for i := len(all) - 1; i >= 0 && len(out) < steps; i-- {
+		migration := all[i]
+		if _, ok := done[migration.Version]; !ok {
+			continue
+		}
+		if migration.Down == "" {
+			return out, fmt.Errorf("migration %04d_%s can not be reverted", migration.Version, migration.Name)
+		}
+		if err := run(ctx, conn, migration, false); err != nil {
+			return out, fmt.Errorf("revert of %04d_%s failed: %w", migration.Version, migration.Name, err)
+		}
+		log.Printf("Reverted migration %04d_%s", migration.Version, migration.Name)
+		out = append(out, migration)
+	}
+	return out, nil
+}
+
+// List returns every known migration together with the time it was applied
+func List(ctx context.Context, conn *pgx.Conn) ([]Status, error) {
+	all, err := Load()
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(ctx, conn); err != nil {
+		return nil, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+	done, err := applied(ctx, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read applied migrations: %w", err)
+	}
+
+	var out []Status
+	for _, migration := range all {
+		status := Status{Version: migration.Version, Name: migration.Name}
+		if applied_at, ok := done[migration.Version]; ok {
+			status.AppliedAt = &applied_at
+		}
+		out = append(out, status)
+	}
+	return out, nil
+}
@@ -1,27 +1,36 @@
 -- name: GetActor :one
-SELECT * FROM Actor
+SELECT * FROM Person
 WHERE id = $1 LIMIT 1;
 
 -- name: ListActors :many
+-- Every person is listed, movies are the ones they acted in
 SELECT 
-    Actor.*,
-    JSON_AGG(json_build_object(
+    Person.*,
+    COALESCE(JSON_AGG(json_build_object(
         'ID', Movie.id,
         'title', Movie.title,
         'plot', Movie.description,
-        'release_date', Movie.release_date
-    )) AS movies
+        'release_date', Movie.release_date,
+        'characters', Credit.characters,
+        'billing_order', Credit.billing_order,
+        'uncredited', Credit.uncredited
+    )) FILTER (WHERE Movie.id IS NOT NULL), '[]'::json) AS movies
 FROM 
-    Actor
+    Person
 LEFT JOIN 
-    MovieActor ON Actor.id = MovieActor.actor_id
+    Credit ON Person.id = Credit.person_id AND Credit.role = 'actor'
 LEFT JOIN 
-    Movie ON MovieActor.movie_id = Movie.id
+    Movie ON Credit.movie_id = Movie.id
+WHERE
+    Person.id > @after_id::int
 GROUP BY 
-    Actor.id;
+    Person.id
+ORDER BY
+    Person.id
+LIMIT @page_limit;
 
 -- name: CreateActor :one
-INSERT INTO Actor (
+INSERT INTO Person (
   name, birth, gender
 ) VALUES (
   $1, $2, $3
@@ -29,31 +38,36 @@ INSERT INTO Actor (
 RETURNING *;
 
 -- name: UpdateActor :exec
-UPDATE Actor
+UPDATE Person
   SET name = COALESCE(sqlc.narg('name'), name),
   birth = COALESCE(sqlc.narg('birth'), birth),
   gender = COALESCE(sqlc.narg('gender'), gender)
 WHERE id = $1;
 
 -- name: DeleteActor :one
-DELETE FROM Actor
+DELETE FROM Person
 WHERE id = $1
 RETURNING id;
 
 -- name: CreateMovie :one
 INSERT INTO Movie (
-  title, description, release_date, rating
+  title, description, release_date, rating, language
 ) VALUES (
-  $1 ,$2, $3, $4 
+  $1 ,$2, $3, $4, $5
 )
 RETURNING *;
 
+-- name: GetMovie :one
+SELECT * FROM Movie
+WHERE id = $1;
+
 -- name: UpdateMovie :exec
 UPDATE Movie
   SET title = COALESCE(sqlc.narg('title'), title),
   description = COALESCE(sqlc.narg('description'), description),
   release_date = COALESCE(sqlc.narg('release_date'), release_date),
-  rating = COALESCE(sqlc.narg('rating'), rating)
+  rating = COALESCE(sqlc.narg('rating'), rating),
+  language = COALESCE(sqlc.narg('language'), language)
 WHERE id = $1;
 
 -- name: DeleteMovie :one
@@ -77,41 +91,978 @@ WHERE username = $1;
 SELECT * FROM AppUser 
 WHERE id = $1;
 
--- name: ListMoviesAsc :many
-SELECT * FROM Movie
-ORDER BY @property::text ASC;
+-- name: UpdateUserPassword :one
+UPDATE AppUser
+  SET password = $2,
+  tokens_valid_after = $3
+WHERE username = $1
+RETURNING id;
 
--- name: ListMoviesDesc :many
-SELECT * FROM Movie
-ORDER BY @property::text DESC;
+-- name: ListUsers :many
+SELECT * FROM AppUser
+ORDER BY id;
+
+-- name: UpdateUserRole :one
+UPDATE AppUser
+  SET role = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: SetUserDisabled :one
+UPDATE AppUser
+  SET disabled = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteUser :one
+DELETE FROM AppUser
+WHERE id = $1
+RETURNING id;
+
+-- name: CreateRefreshToken :exec
+INSERT INTO RefreshToken (
+  id, family_id, user_id, expires_at
+) VALUES (
+  $1, $2, $3, $4
+);
+
+-- name: GetRefreshToken :one
+SELECT * FROM RefreshToken
+WHERE id = $1;
+
+-- name: UseRefreshToken :one
+UPDATE RefreshToken
+  SET used = true
+WHERE id = $1 AND used = false AND revoked = false
+RETURNING *;
+
+-- name: RevokeRefreshTokenFamily :exec
+UPDATE RefreshToken
+  SET revoked = true
+WHERE family_id = $1;
+
+-- name: RevokeUserRefreshTokens :exec
+UPDATE RefreshToken
+  SET revoked = true
+WHERE user_id = $1;
+
+-- name: RevokeToken :exec
+INSERT INTO RevokedToken (
+  jti, user_id, expires_at
+) VALUES (
+  $1, $2, $3
+)
+ON CONFLICT (jti) DO NOTHING;
+
+-- name: IsTokenRevoked :one
+SELECT EXISTS (
+  SELECT 1 FROM RevokedToken WHERE jti = $1
+);
+
+-- name: DeleteExpiredRevokedTokens :exec
+DELETE FROM RevokedToken
+WHERE expires_at < now();
+
+-- name: RevokeUserTokens :exec
+UPDATE AppUser
+  SET tokens_valid_after = $2
+WHERE id = $1;
+
+-- name: CountMovies :one
+SELECT COUNT(*) FROM Movie;
+
+-- name: ListMovies :many
+-- Keyset pagination over up to three sort keys chosen at runtime, an empty key is ignored.
+-- It sorts every filtered movie, so listings with a single key use ListMoviesByKey instead.
+-- The cursor movie goes through the same key expressions as the listed movies,
+-- then every key of a listed movie is compared with the key of the cursor to 1 (after), -1 (before)
+-- or 0 (equal) and the first difference decides whether it sorts after the cursor. Ties are broken by id.
+-- Filters are skipped when their parameter is null, see filter_movies.
+WITH candidates AS (
+  SELECT Movie.id, Movie.title, Movie.release_date, Movie.rating, false AS is_cursor
+  FROM filter_movies(
+      sqlc.narg('released_after')::date,
+      sqlc.narg('released_before')::date,
+      sqlc.narg('min_rating')::numeric,
+      sqlc.narg('max_rating')::numeric,
+      sqlc.narg('has_description')::bool,
+      sqlc.narg('title_prefix')::text,
+      sqlc.narg('actor_ids')::int[],
+      sqlc.narg('director_ids')::int[],
+      sqlc.narg('genre_ids')::int[]
+    ) AS filtered(id)
+  JOIN Movie ON Movie.id = filtered.id
+  UNION ALL
+  SELECT
+    sqlc.narg('cursor_id')::int,
+    sqlc.narg('cursor_title')::text,
+    sqlc.narg('cursor_release_date')::date,
+    sqlc.narg('cursor_rating')::numeric,
+    true
+  WHERE sqlc.narg('cursor_id')::int IS NOT NULL
+), keyed AS (
+  SELECT
+    id,
+    is_cursor,
+    CASE @sort_key_1::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> @nulls_first::bool AS key_null_1,
+    CASE @sort_key_1::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN @sort_desc_1::bool THEN -1 ELSE 1 END AS key_number_1,
+    CASE WHEN @sort_key_1::text = 'title' AND NOT @sort_desc_1::bool THEN title END AS key_asc_1,
+    CASE WHEN @sort_key_1::text = 'title' AND @sort_desc_1::bool THEN title END AS key_desc_1,
+    CASE @sort_key_2::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> @nulls_first::bool AS key_null_2,
+    CASE @sort_key_2::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN @sort_desc_2::bool THEN -1 ELSE 1 END AS key_number_2,
+    CASE WHEN @sort_key_2::text = 'title' AND NOT @sort_desc_2::bool THEN title END AS key_asc_2,
+    CASE WHEN @sort_key_2::text = 'title' AND @sort_desc_2::bool THEN title END AS key_desc_2,
+    CASE @sort_key_3::text
+      WHEN 'rating' THEN rating IS NULL
+      WHEN 'date' THEN release_date IS NULL
+      ELSE false
+    END <> @nulls_first::bool AS key_null_3,
+    CASE @sort_key_3::text
+      WHEN 'rating' THEN rating
+      WHEN 'date' THEN (release_date - DATE '1970-01-01')::numeric
+    END * CASE WHEN @sort_desc_3::bool THEN -1 ELSE 1 END AS key_number_3,
+    CASE WHEN @sort_key_3::text = 'title' AND NOT @sort_desc_3::bool THEN title END AS key_asc_3,
+    CASE WHEN @sort_key_3::text = 'title' AND @sort_desc_3::bool THEN title END AS key_desc_3
+  FROM candidates
+)
+SELECT Movie.*
+FROM keyed k
+JOIN Movie ON Movie.id = k.id AND NOT k.is_cursor
+LEFT JOIN keyed c ON c.is_cursor
+WHERE c.id IS NULL OR (
+    CASE
+      WHEN k.key_null_1 <> c.key_null_1 THEN CASE WHEN k.key_null_1 THEN 1 ELSE -1 END
+      WHEN k.key_number_1 <> c.key_number_1 THEN sign(k.key_number_1 - c.key_number_1)::int
+      WHEN k.key_asc_1 <> c.key_asc_1 THEN CASE WHEN k.key_asc_1 > c.key_asc_1 THEN 1 ELSE -1 END
+      WHEN k.key_desc_1 <> c.key_desc_1 THEN CASE WHEN k.key_desc_1 < c.key_desc_1 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    CASE
+      WHEN k.key_null_2 <> c.key_null_2 THEN CASE WHEN k.key_null_2 THEN 1 ELSE -1 END
+      WHEN k.key_number_2 <> c.key_number_2 THEN sign(k.key_number_2 - c.key_number_2)::int
+      WHEN k.key_asc_2 <> c.key_asc_2 THEN CASE WHEN k.key_asc_2 > c.key_asc_2 THEN 1 ELSE -1 END
+      WHEN k.key_desc_2 <> c.key_desc_2 THEN CASE WHEN k.key_desc_2 < c.key_desc_2 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    CASE
+      WHEN k.key_null_3 <> c.key_null_3 THEN CASE WHEN k.key_null_3 THEN 1 ELSE -1 END
+      WHEN k.key_number_3 <> c.key_number_3 THEN sign(k.key_number_3 - c.key_number_3)::int
+      WHEN k.key_asc_3 <> c.key_asc_3 THEN CASE WHEN k.key_asc_3 > c.key_asc_3 THEN 1 ELSE -1 END
+      WHEN k.key_desc_3 <> c.key_desc_3 THEN CASE WHEN k.key_desc_3 < c.key_desc_3 THEN 1 ELSE -1 END
+      ELSE 0
+    END,
+    sign(k.id - c.id)::int
+  ) > (0, 0, 0, 0)
+ORDER BY
+  k.key_null_1, k.key_number_1, k.key_asc_1, k.key_desc_1 DESC,
+  k.key_null_2, k.key_number_2, k.key_asc_2, k.key_desc_2 DESC,
+  k.key_null_3, k.key_number_3, k.key_asc_3, k.key_desc_3 DESC,
+  k.id
+LIMIT @page_limit;
+
+-- name: ListMoviesByKey :many
+-- ListMovies for a single sort key. Every branch reads one segment of one order straight from
+-- the sort indexes of Movie and stops at the page size, branches of other orders are skipped.
+-- Movies with an empty key form their own segment ordered by id, nulls_first places it first.
+-- Only the few rows of the active branches are sorted into the page.
+WITH filtered AS NOT MATERIALIZED (
+  SELECT filtered.id
+  FROM filter_movies(
+      sqlc.narg('released_after')::date,
+      sqlc.narg('released_before')::date,
+      sqlc.narg('min_rating')::numeric,
+      sqlc.narg('max_rating')::numeric,
+      sqlc.narg('has_description')::bool,
+      sqlc.narg('title_prefix')::text,
+      sqlc.narg('actor_ids')::int[],
+      sqlc.narg('director_ids')::int[],
+      sqlc.narg('genre_ids')::int[]
+    ) AS filtered(id)
+)
+SELECT id, title, description, release_date, rating, language
+FROM (
+  (
+    SELECT Movie.*, @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.rating, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'rating'
+      AND NOT @sort_desc::bool
+      AND Movie.rating IS NOT NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_rating')::numeric IS NULL AND @nulls_first::bool
+        OR (Movie.rating, Movie.id) > (sqlc.narg('cursor_rating')::numeric, sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.rating, Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.rating DESC, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'rating'
+      AND @sort_desc::bool
+      AND Movie.rating IS NOT NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_rating')::numeric IS NULL AND @nulls_first::bool
+        OR Movie.rating <= sqlc.narg('cursor_rating')::numeric
+          AND (Movie.rating < sqlc.narg('cursor_rating')::numeric OR Movie.id > sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.rating DESC, Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, NOT @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'rating'
+      AND Movie.rating IS NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_rating')::numeric IS NOT NULL AND NOT @nulls_first::bool
+        OR sqlc.narg('cursor_rating')::numeric IS NULL AND Movie.id > sqlc.narg('cursor_id')::int)
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.release_date, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'date'
+      AND NOT @sort_desc::bool
+      AND Movie.release_date IS NOT NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_release_date')::date IS NULL AND @nulls_first::bool
+        OR (Movie.release_date, Movie.id) > (sqlc.narg('cursor_release_date')::date, sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.release_date, Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.release_date DESC, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'date'
+      AND @sort_desc::bool
+      AND Movie.release_date IS NOT NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_release_date')::date IS NULL AND @nulls_first::bool
+        OR Movie.release_date <= sqlc.narg('cursor_release_date')::date
+          AND (Movie.release_date < sqlc.narg('cursor_release_date')::date OR Movie.id > sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.release_date DESC, Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, NOT @nulls_first::bool AS segment, row_number() OVER (ORDER BY Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'date'
+      AND Movie.release_date IS NULL
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR sqlc.narg('cursor_release_date')::date IS NOT NULL AND NOT @nulls_first::bool
+        OR sqlc.narg('cursor_release_date')::date IS NULL AND Movie.id > sqlc.narg('cursor_id')::int)
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, false AS segment, row_number() OVER (ORDER BY Movie.title, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'title'
+      AND NOT @sort_desc::bool
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR (Movie.title, Movie.id) > (sqlc.narg('cursor_title')::text, sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.title, Movie.id
+    LIMIT @page_limit
+  )
+  UNION ALL
+  (
+    SELECT Movie.*, false AS segment, row_number() OVER (ORDER BY Movie.title DESC, Movie.id) AS position
+    FROM Movie
+    WHERE @sort_key::text = 'title'
+      AND @sort_desc::bool
+      AND (sqlc.narg('cursor_id')::int IS NULL
+        OR Movie.title <= sqlc.narg('cursor_title')::text
+          AND (Movie.title < sqlc.narg('cursor_title')::text OR Movie.id > sqlc.narg('cursor_id')::int))
+      AND Movie.id IN (SELECT id FROM filtered)
+    ORDER BY Movie.title DESC, Movie.id
+    LIMIT @page_limit
+  )
+) AS page
+ORDER BY segment, position
+LIMIT @page_limit;
 
 -- name: SearchMovie :many
+-- The query is tsquery text, every movie matches when it is null.
+-- The query is built once for every configuration a document can have and each document
+-- is matched with the query of its own configuration, so every branch can use the document index.
+-- With a language only movies of the language are searched.
+-- Movies must have a cast member containing every name of actors and none of excluded_actors,
+-- a director containing every name of directors and none of excluded_directors,
+-- and every genre of genre_ids.
+-- Results are ordered by relevance, then by id.
+WITH search AS (
+  SELECT
+    to_tsquery('english', sqlc.narg('query')::text) AS english,
+    to_tsquery('russian', sqlc.narg('query')::text) AS russian,
+    to_tsquery('simple', sqlc.narg('query')::text) AS simple
+), matches AS (
+  SELECT
+    MovieSearch.movie_id,
+    COALESCE(ts_rank_cd(MovieSearch.document, CASE MovieSearch.config
+      WHEN 'english'::regconfig THEN search.english
+      WHEN 'russian'::regconfig THEN search.russian
+      ELSE search.simple
+    END), 0)::real AS score
+  FROM MovieSearch, search
+  WHERE sqlc.narg('query')::text IS NULL
+    OR (MovieSearch.config = 'english'::regconfig AND MovieSearch.document @@ search.english)
+    OR (MovieSearch.config = 'russian'::regconfig AND MovieSearch.document @@ search.russian)
+    OR (MovieSearch.config = 'simple'::regconfig AND MovieSearch.document @@ search.simple)
+)
+SELECT sqlc.embed(Movie), matches.score
+FROM matches
+JOIN Movie ON Movie.id = matches.movie_id
+WHERE (sqlc.narg('after_score')::real IS NULL
+    OR matches.score < sqlc.narg('after_score')::real
+    OR (matches.score = sqlc.narg('after_score')::real AND Movie.id > @after_id::int))
+  AND (sqlc.narg('language')::text IS NULL OR Movie.language = sqlc.narg('language')::text)
+  AND (sqlc.narg('year_from')::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) >= sqlc.narg('year_from')::int)
+  AND (sqlc.narg('year_to')::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) <= sqlc.narg('year_to')::int)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@actors::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest(@excluded_actors::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@directors::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest(@excluded_directors::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@genre_ids::int[]) AS wanted(genre_id)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM MovieGenre
+      WHERE MovieGenre.movie_id = Movie.id AND MovieGenre.genre_id = wanted.genre_id
+    )
+  )
+ORDER BY matches.score DESC, Movie.id
+LIMIT @page_limit;
+
+-- name: SetSimilarityThreshold :exec
+-- Threshold of the <% operator for the rest of the transaction
+SELECT set_config('pg_trgm.word_similarity_threshold', (@threshold::real)::text, true);
+
+-- name: FuzzySearchMovie :many
+-- Movies whose title or the name of a credited person is similar to the query.
+-- Filters are the same as in SearchMovie.
+-- Run SetSimilarityThreshold in the same transaction to change the threshold.
+WITH matches AS (
+  SELECT Movie.id, word_similarity(@query::text, Movie.title) AS score
+  FROM Movie
+  WHERE @query::text <% Movie.title
+  UNION ALL
+  SELECT Credit.movie_id, word_similarity(@query::text, Person.name)
+  FROM Person
+  JOIN Credit ON Credit.person_id = Person.id
+  WHERE @query::text <% Person.name
+), scored AS (
+  SELECT id, MAX(score)::real AS score
+  FROM matches
+  GROUP BY id
+)
+SELECT sqlc.embed(Movie), scored.score
+FROM scored
+JOIN Movie ON Movie.id = scored.id
+WHERE (sqlc.narg('after_score')::real IS NULL
+    OR scored.score < sqlc.narg('after_score')::real
+    OR (scored.score = sqlc.narg('after_score')::real AND Movie.id > @after_id::int))
+  AND (sqlc.narg('language')::text IS NULL OR Movie.language = sqlc.narg('language')::text)
+  AND (sqlc.narg('year_from')::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) >= sqlc.narg('year_from')::int)
+  AND (sqlc.narg('year_to')::int IS NULL OR EXTRACT(YEAR FROM Movie.release_date) <= sqlc.narg('year_to')::int)
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@actors::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest(@excluded_actors::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'actor'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@directors::text[]) AS pattern
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM Credit
+      JOIN Person ON Person.id = Credit.person_id
+      WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+        AND strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    )
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM Credit
+    JOIN Person ON Person.id = Credit.person_id
+    JOIN unnest(@excluded_directors::text[]) AS pattern ON strpos(LOWER(Person.name), LOWER(pattern)) > 0
+    WHERE Credit.movie_id = Movie.id AND Credit.role = 'director'
+  )
+  AND NOT EXISTS (
+    SELECT 1
+    FROM unnest(@genre_ids::int[]) AS wanted(genre_id)
+    WHERE NOT EXISTS (
+      SELECT 1
+      FROM MovieGenre
+      WHERE MovieGenre.movie_id = Movie.id AND MovieGenre.genre_id = wanted.genre_id
+    )
+  )
+ORDER BY scored.score DESC, Movie.id
+LIMIT @page_limit;
+
+-- name: SearchSuggestions :many
+-- Movie titles and names of people similar to the query, most similar first.
+-- Run SetSimilarityThreshold in the same transaction to change the threshold.
+SELECT suggestion::text, MAX(score)::real AS score
+FROM (
+  SELECT Movie.title AS suggestion, word_similarity(@query::text, Movie.title) AS score
+  FROM Movie
+  WHERE @query::text <% Movie.title
+  UNION ALL
+  SELECT Person.name, word_similarity(@query::text, Person.name)
+  FROM Person
+  WHERE @query::text <% Person.name
+) AS candidates
+GROUP BY suggestion
+ORDER BY score DESC, suggestion
+LIMIT @suggestion_limit;
+
+-- name: Suggest :many
+-- Movie titles and names of people starting with the prefix,
+-- people are labeled as actors since actor endpoints serve every person.
+-- Every kind takes up to candidate_limit matches in the order of its prefix index,
+-- only those are ranked: exact matches go first, then shorter labels.
+-- Appending the largest code point gives the upper bound of the prefix range.
+WITH bounds AS (
+  SELECT LOWER(@prefix::text) AS low, LOWER(@prefix::text) || chr(1114111) AS high
+)
+SELECT kind::text, id, label::text
+FROM (
+  (
+    SELECT 'movie' AS kind, Movie.id, Movie.title AS label
+    FROM Movie, bounds
+    WHERE LOWER(Movie.title) ~>=~ bounds.low AND LOWER(Movie.title) ~<~ bounds.high
+    ORDER BY LOWER(Movie.title) USING ~<~
+    LIMIT @candidate_limit
+  )
+  UNION ALL
+  (
+    SELECT 'actor', Person.id, Person.name
+    FROM Person, bounds
+    WHERE LOWER(Person.name) ~>=~ bounds.low AND LOWER(Person.name) ~<~ bounds.high
+    ORDER BY LOWER(Person.name) USING ~<~
+    LIMIT @candidate_limit
+  )
+) AS candidates
+ORDER BY LOWER(label) = LOWER(@prefix::text) DESC, length(label), label, id
+LIMIT @suggest_limit;
+
+-- name: HighlightMovies :many
+-- Title and description of the movies with the query terms wrapped into markers.
+-- Markers are ts_headline options like StartSel="<b>", StopSel="</b>".
+-- Every movie is highlighted with the configuration of its search document.
+SELECT
+  Movie.id,
+  ts_headline(search.config, Movie.title, search.query, @markers::text || ', HighlightAll=true')::text AS title,
+  ts_headline(search.config, COALESCE(Movie.description, ''), search.query,
+    @markers::text || ', MaxFragments=2, MaxWords=30, MinWords=10')::text AS description
+FROM Movie
+JOIN MovieSearch ON MovieSearch.movie_id = Movie.id
+CROSS JOIN LATERAL (
+  SELECT
+    MovieSearch.config,
+    to_tsquery(MovieSearch.config, @query::text) AS query
+) AS search
+WHERE Movie.id = ANY(@ids::int[]);
+
+-- name: ListActorMovies :many
 SELECT Movie.*
-FROM Movie 
-LEFT JOIN 
-    MovieActor ON Movie.id = MovieActor.movie_id
-LEFT JOIN 
-    Actor ON MovieActor.actor_id = Actor.id
-WHERE 
-  title @@ to_tsquery($1)
-OR
-  LOWER(actor.name) LIKE LOWER($2);;
-
--- name: ActorMovies :many
-SELECT m.* 
-FROM Movie m
-LEFT JOIN MovieActor ma ON m.movie_id = ma.movie_id
-WHERE ma.actor_id = 1;
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role = 'actor'
+ORDER BY Movie.release_date, Movie.id;
 
 -- name: CreateMovieActor :exec
-INSERT INTO MovieActor (
-  movie_id, actor_id
+INSERT INTO Credit (
+  movie_id, person_id, role, characters, billing_order
 ) VALUES (
-  $1, $2
+  $1, $2, 'actor', $3, $4
 );
 
+-- name: CreateMovieCast :exec
+-- entries is a JSON array of objects with actor_id, characters, billing_order, uncredited and bare.
+-- Actors that are already in the cast get their details replaced,
+-- unless the entry is bare, that is only an actor id was sent.
+INSERT INTO Credit (
+  movie_id, person_id, role, characters, billing_order, uncredited
+)
+SELECT @movie_id::int, entry.actor_id, 'actor', COALESCE(entry.characters, '{}'), entry.billing_order, COALESCE(entry.uncredited, false)
+FROM jsonb_to_recordset(@entries::jsonb) AS entry(actor_id int, characters text[], billing_order int, uncredited bool, bare bool)
+WHERE NOT (COALESCE(entry.bare, false) AND EXISTS (
+  SELECT 1
+  FROM Credit
+  WHERE Credit.movie_id = @movie_id::int AND Credit.person_id = entry.actor_id AND Credit.role = 'actor'
+))
+ON CONFLICT (movie_id, person_id, role) DO UPDATE SET
+  characters = EXCLUDED.characters,
+  billing_order = EXCLUDED.billing_order,
+  uncredited = EXCLUDED.uncredited;
+
+-- name: ListExistingActorIds :many
+SELECT id FROM Person
+WHERE id = ANY(@ids::int[]);
+
+-- name: ListMovieCast :many
+-- Billed actors go first in billing order, uncredited actors go last
+SELECT sqlc.embed(Person), Credit.characters, Credit.billing_order, Credit.uncredited
+FROM Person
+JOIN Credit ON Person.id = Credit.person_id
+WHERE Credit.movie_id = $1 AND Credit.role = 'actor'
+ORDER BY Credit.uncredited, Credit.billing_order NULLS LAST, Person.name, Person.id;
+
+-- name: ListActorFilmography :many
+SELECT sqlc.embed(Movie), Credit.characters, Credit.billing_order, Credit.uncredited
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role = 'actor'
+ORDER BY Movie.release_date, Movie.id;
+
+-- name: CreateActorMovies :exec
+INSERT INTO Credit (
+  movie_id, person_id, role
+)
+SELECT unnest(@movie_ids::int[]), @actor_id::int, 'actor'
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMovieActors :exec
+DELETE FROM Credit
+WHERE movie_id = @movie_id AND role = 'actor' AND person_id = ANY(@actor_ids::int[]);
+
+-- name: DeleteActorMovies :exec
+DELETE FROM Credit
+WHERE person_id = @actor_id AND role = 'actor' AND movie_id = ANY(@movie_ids::int[]);
+
+-- name: ClearMovieCast :exec
+DELETE FROM Credit
+WHERE movie_id = $1 AND role = 'actor';
+
+-- name: ListMovieCrew :many
+-- Credits of the movie other than acting
+SELECT sqlc.embed(Person), Credit.role
+FROM Person
+JOIN Credit ON Person.id = Credit.person_id
+WHERE Credit.movie_id = $1 AND Credit.role <> 'actor'
+ORDER BY Credit.role, Person.name, Person.id;
+
+-- name: ListPersonCrewMovies :many
+-- Movies the person worked on in roles other than acting
+SELECT sqlc.embed(Movie), Credit.role
+FROM Movie
+JOIN Credit ON Movie.id = Credit.movie_id
+WHERE Credit.person_id = $1 AND Credit.role <> 'actor'
+ORDER BY Movie.release_date, Movie.id, Credit.role;
+
+-- name: CreateMovieCredits :exec
+-- person_ids and roles are parallel arrays
+INSERT INTO Credit (
+  movie_id, person_id, role
+)
+SELECT @movie_id::int, credit.person_id, credit.role::credit_role
+FROM unnest(@person_ids::int[], @roles::text[]) AS credit(person_id, role)
+ON CONFLICT DO NOTHING;
+
+-- name: DeleteMovieCredits :exec
+-- person_ids and roles are parallel arrays
+DELETE FROM Credit
+USING unnest(@person_ids::int[], @roles::text[]) AS credit(person_id, role)
+WHERE Credit.movie_id = @movie_id
+  AND Credit.person_id = credit.person_id
+  AND Credit.role = credit.role::credit_role;
+
+-- name: ClearMovieCrew :exec
+DELETE FROM Credit
+WHERE movie_id = $1 AND role <> 'actor';
+
+-- name: ListExistingMovieIds :many
+SELECT id FROM Movie
+WHERE id = ANY(@ids::int[]);
+
+-- name: LockMovie :one
+SELECT id FROM Movie
+WHERE id = $1
+FOR UPDATE;
+
+-- name: LockActor :one
+SELECT id FROM Person
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CreateMaintenanceRun :one
+INSERT INTO MaintenanceRun (
+  task, triggered_by
+) VALUES (
+  $1, $2
+)
+RETURNING *;
+
+-- name: FinishMaintenanceRun :exec
+UPDATE MaintenanceRun
+  SET status = $2,
+  error = $3,
+  finished_at = now()
+WHERE id = $1;
+
+-- name: FailInterruptedMaintenanceRuns :execrows
+-- Runs that were still running when their server stopped
+UPDATE MaintenanceRun
+  SET status = 'failed',
+  error = 'interrupted',
+  finished_at = now()
+WHERE status = 'running';
+
+-- name: GetMaintenanceRun :one
+SELECT * FROM MaintenanceRun
+WHERE id = $1;
+
+-- name: ListMaintenanceRuns :many
+SELECT * FROM MaintenanceRun
+ORDER BY id DESC
+LIMIT $1;
+
+-- name: CreateGenre :one
+INSERT INTO Genre (
+  name
+) VALUES (
+  $1
+)
+RETURNING *;
+
+-- name: UpdateGenre :one
+UPDATE Genre
+  SET name = $2
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteGenre :one
+DELETE FROM Genre
+WHERE id = $1
+RETURNING id;
+
+-- name: ListGenres :many
+SELECT Genre.id, Genre.name, COUNT(MovieGenre.movie_id)::int AS movie_count
+FROM Genre
+LEFT JOIN MovieGenre ON MovieGenre.genre_id = Genre.id
+GROUP BY Genre.id
+ORDER BY Genre.name;
+
+-- name: ListExistingGenreIds :many
+SELECT id FROM Genre
+WHERE id = ANY(@ids::int[]);
+
+-- name: ListMovieGenres :many
+SELECT Genre.*
+FROM Genre
+JOIN MovieGenre ON MovieGenre.genre_id = Genre.id
+WHERE MovieGenre.movie_id = $1
+ORDER BY Genre.name;
+
+-- name: CreateMovieGenres :exec
+INSERT INTO MovieGenre (
+  movie_id, genre_id
+)
+SELECT @movie_id::int, unnest(@genre_ids::int[])
+ON CONFLICT DO NOTHING;
+
+-- name: ClearMovieGenres :exec
+DELETE FROM MovieGenre
+WHERE movie_id = $1;
+
+-- name: CountMovieGenres :many
+-- Number of movies in every genre among the movies that pass the ListMovies filters
+SELECT Genre.id, Genre.name, COUNT(*)::int AS movie_count
+FROM filter_movies(
+    sqlc.narg('released_after')::date,
+    sqlc.narg('released_before')::date,
+    sqlc.narg('min_rating')::numeric,
+    sqlc.narg('max_rating')::numeric,
+    sqlc.narg('has_description')::bool,
+    sqlc.narg('title_prefix')::text,
+    sqlc.narg('actor_ids')::int[],
+    sqlc.narg('director_ids')::int[],
+    sqlc.narg('genre_ids')::int[]
+) AS filtered(id)
+JOIN MovieGenre ON MovieGenre.movie_id = filtered.id
+JOIN Genre ON Genre.id = MovieGenre.genre_id
+GROUP BY Genre.id
+ORDER BY movie_count DESC, Genre.name;
+
+
+
+-- name: CreateReview :one
+INSERT INTO Review (
+  movie_id, user_id, rating, body
+) VALUES (
+  $1, $2, $3, $4
+)
+RETURNING *;
+
+-- name: GetReview :one
+SELECT * FROM Review
+WHERE id = $1;
+
+-- name: UpdateReview :one
+-- An empty body removes it
+UPDATE Review
+  SET rating = COALESCE(sqlc.narg('rating'), rating),
+  body = NULLIF(COALESCE(sqlc.narg('body'), body), ''),
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteReview :one
+DELETE FROM Review
+WHERE id = $1
+RETURNING id;
+
+-- name: ListMovieReviews :many
+-- Newest reviews first
+SELECT sqlc.embed(Review), AppUser.username
+FROM Review
+JOIN AppUser ON AppUser.id = Review.user_id
+WHERE Review.movie_id = @movie_id
+  AND (sqlc.narg('before_id')::int IS NULL OR Review.id < sqlc.narg('before_id')::int)
+ORDER BY Review.id DESC
+LIMIT @page_limit;
+
+-- name: GetCommunityRating :one
+-- histogram holds the number of reviews for every rating from 0 to 10, mean is null without reviews
+WITH buckets AS (
+  SELECT bucket.rating, COUNT(Review.id) AS votes
+  FROM generate_series(0, 10) AS bucket(rating)
+  LEFT JOIN Review ON Review.movie_id = $1 AND Review.rating = bucket.rating
+  GROUP BY bucket.rating
+)
+SELECT
+  SUM(votes)::int AS review_count,
+  ROUND(SUM(rating * votes) / NULLIF(SUM(votes), 0), 2)::numeric AS mean,
+  ARRAY_AGG(votes::int ORDER BY rating)::int[] AS histogram
+FROM buckets;
+
+-- name: AddToWatchlist :one
+-- Adding a movie twice keeps the original date
+INSERT INTO Watchlist (user_id, movie_id)
+VALUES (@user_id, @movie_id)
+ON CONFLICT (user_id, movie_id) DO UPDATE
+SET added_at = Watchlist.added_at
+RETURNING *;
+
+-- name: RemoveFromWatchlist :one
+DELETE FROM Watchlist
+WHERE user_id = @user_id AND movie_id = @movie_id
+RETURNING movie_id;
+
+-- name: ListWatchlist :many
+-- Recently added movies first
+SELECT sqlc.embed(Movie), Watchlist.added_at
+FROM Watchlist
+JOIN Movie ON Movie.id = Watchlist.movie_id
+WHERE Watchlist.user_id = @user_id
+  AND (sqlc.narg('before_added_at')::timestamptz IS NULL
+    OR (Watchlist.added_at, Watchlist.movie_id) < (sqlc.narg('before_added_at')::timestamptz, sqlc.narg('before_movie_id')::int))
+ORDER BY Watchlist.added_at DESC, Watchlist.movie_id DESC
+LIMIT @page_limit;
+
+-- name: LogWatched :one
+-- Logging a watched movie again keeps the latest date and counts a rewatch unless the count is given
+INSERT INTO Watched (user_id, movie_id, watched_on, rewatch_count)
+VALUES (
+  @user_id,
+  @movie_id,
+  COALESCE(sqlc.narg('watched_on')::date, CURRENT_DATE),
+  COALESCE(sqlc.narg('rewatch_count')::int, 0)
+)
+ON CONFLICT (user_id, movie_id) DO UPDATE
+SET watched_on = GREATEST(Watched.watched_on, EXCLUDED.watched_on),
+    rewatch_count = COALESCE(sqlc.narg('rewatch_count')::int, Watched.rewatch_count + 1)
+RETURNING *;
+
+-- name: RemoveWatched :one
+DELETE FROM Watched
+WHERE user_id = @user_id AND movie_id = @movie_id
+RETURNING movie_id;
+
+-- name: ListWatched :many
+-- Recently watched movies first
+SELECT sqlc.embed(Movie), Watched.watched_on, Watched.rewatch_count
+FROM Watched
+JOIN Movie ON Movie.id = Watched.movie_id
+WHERE Watched.user_id = @user_id
+  AND (sqlc.narg('before_watched_on')::date IS NULL
+    OR (Watched.watched_on, Watched.movie_id) < (sqlc.narg('before_watched_on')::date, sqlc.narg('before_movie_id')::int))
+ORDER BY Watched.watched_on DESC, Watched.movie_id DESC
+LIMIT @page_limit;
+
+-- name: ListUserMovieFlags :many
+-- Watchlist and watched state of the given movies for the user
+SELECT
+  Movie.id,
+  EXISTS (
+    SELECT 1 FROM Watchlist
+    WHERE Watchlist.user_id = @user_id AND Watchlist.movie_id = Movie.id
+  ) AS in_watchlist,
+  EXISTS (
+    SELECT 1 FROM Watched
+    WHERE Watched.user_id = @user_id AND Watched.movie_id = Movie.id
+  ) AS watched
+FROM Movie
+WHERE Movie.id = ANY(@movie_ids::int[]);
+
+-- name: CreateCollection :one
+INSERT INTO Collection (
+  user_id, name, description, visibility, share_token
+) VALUES (
+  $1, $2, $3, $4, $5
+)
+RETURNING *;
+
+-- name: GetCollection :one
+SELECT * FROM Collection
+WHERE id = $1;
+
+-- name: GetCollectionByShareToken :one
+SELECT * FROM Collection
+WHERE share_token = $1;
+
+-- name: ListUserCollections :many
+-- Newest collections first
+SELECT * FROM Collection
+WHERE user_id = @user_id
+  AND (sqlc.narg('before_id')::int IS NULL OR id < sqlc.narg('before_id')::int)
+ORDER BY id DESC
+LIMIT @page_limit;
+
+-- name: UpdateCollection :one
+-- An empty description removes it
+UPDATE Collection
+  SET name = COALESCE(sqlc.narg('name'), name),
+  description = NULLIF(COALESCE(sqlc.narg('description'), description), ''),
+  visibility = COALESCE(sqlc.narg('visibility'), visibility),
+  updated_at = now()
+WHERE id = $1
+RETURNING *;
+
+-- name: SetCollectionShareToken :one
+UPDATE Collection
+SET share_token = @share_token, updated_at = now()
+WHERE id = @id
+RETURNING *;
+
+-- name: LockCollection :one
+SELECT id FROM Collection
+WHERE id = $1
+FOR UPDATE;
+
+-- name: DeleteCollection :one
+DELETE FROM Collection
+WHERE id = $1
+RETURNING id;
+
+-- name: ListCollectionItems :many
+SELECT sqlc.embed(Movie), CollectionItem.position, CollectionItem.note
+FROM CollectionItem
+JOIN Movie ON Movie.id = CollectionItem.movie_id
+WHERE CollectionItem.collection_id = $1
+ORDER BY CollectionItem.position;
+
+-- name: AddCollectionItem :one
+-- The movie is placed after the last item
+INSERT INTO CollectionItem (collection_id, movie_id, position, note)
+SELECT @collection_id, @movie_id, COALESCE(MAX(position), 0) + 1, @note
+FROM CollectionItem
+WHERE collection_id = @collection_id
+RETURNING *;
+
+-- name: UpdateCollectionItem :one
+UPDATE CollectionItem
+SET note = @note
+WHERE collection_id = @collection_id AND movie_id = @movie_id
+RETURNING *;
+
+-- name: DeleteCollectionItem :one
+DELETE FROM CollectionItem
+WHERE collection_id = @collection_id AND movie_id = @movie_id
+RETURNING movie_id;
+
+-- name: ReorderCollectionItems :execrows
+-- Items get positions in the order of movie_ids, starting from 1
+UPDATE CollectionItem
+SET position = ordered.position::int
+FROM unnest(@movie_ids::int[]) WITH ORDINALITY AS ordered(movie_id, position)
+WHERE CollectionItem.collection_id = @collection_id
+  AND CollectionItem.movie_id = ordered.movie_id;
 
 -- name: ClearDatabase :exec
 DROP TABLE AppUser;
-DROP TABLE Actor;
+DROP TABLE Person;
 DROP TABLE Movie;
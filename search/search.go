@@ -0,0 +1,294 @@
+// Package search parses queries of the search endpoint.
+//
+// A query is a list of terms that all must match:
+//
+//	word           a word of the title, description, cast or crew, matched as a prefix
+//	"a phrase"     words that follow each other
+//	-term          a word or phrase that must not match
+//	a OR b         either side matches, binds weaker than the implicit AND
+//	actor:name     a cast member whose name contains name, -actor:name excludes such movies
+//	director:name  a director whose name contains name, -director:name excludes such movies
+//	year:1999      release year, ranges are written as 1990..1999, 1990.. or ..1999
+//
+// Words are compiled into tsquery text where every lexeme is quoted,
+// so user input never reaches the tsquery syntax.
+package search
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Query struct {
+	// TSQuery is the text for to_tsquery, empty when the query has no words
+	TSQuery string
+	// Words are the matched words and phrases without operators
+	Words             []string
+	Actors            []string
+	ExcludedActors    []string
+	Directors         []string
+	ExcludedDirectors []string
+	// YearFrom and YearTo are 0 when not set
+	YearFrom int
+	YearTo   int
+}
+
+// HasFilters reports whether the query contains field filters
+func (self Query) HasFilters() bool {
+	return len(self.Actors) != 0 || len(self.ExcludedActors) != 0 ||
+		len(self.Directors) != 0 || len(self.ExcludedDirectors) != 0 ||
+		self.YearFrom != 0 || self.YearTo != 0
+}
+
+// Plain returns the matched words joined by spaces, it is used for similarity search
+func (self Query) Plain() string {
+	return strings.Join(self.Words, " ")
+}
+
+type ParseError struct {
+	Position int
+	Message  string
+}
+
+func (self *ParseError) Error() string {
+	return fmt.Sprintf("%s at position %d", self.Message, self.Position)
+}
+
+func parse_error(position int, format string, args ...any) *ParseError {
+	return &ParseError{Position: position + 1, Message: fmt.Sprintf(format, args...)}
+}
+
+type term struct {
+	position int
+	text     string
+	field    string
+	phrase   bool
+	negated  bool
+	or       bool
+}
+
+func is_separator(r rune) bool {
+	return unicode.IsSpace(r) || r == '(' || r == ')'
+}
+
+// read_quoted reads a quoted string that starts at position and returns the text and the position after it
+func read_quoted(input []rune, position int) (string, int, error) {
+	end := position + 1
+	for end < len(input) && input[end] != '"' {
+		end++
+	}
+	if end == len(input) {
+		return "", 0, parse_error(position, "unterminated quote")
+	}
+	return string(input[position+1 : end]), end + 1, nil
+}
+
+func tokenize(input []rune) ([]term, error) {
+	var out []term
+	i := 0
+	for i < len(input) {
+		if is_separator(input[i]) {
+			i++
+			continue
+		}
+
+		current := term{position: i}
+		if input[i] == '-' {
+			current.negated = true
+			i++
+			if i == len(input) || is_separator(input[i]) {
+				return nil, parse_error(current.position, "expected a term after -")
+			}
+		}
+
+		if input[i] == '"' {
+			text, next, err := read_quoted(input, i)
+			if err != nil {
+				return nil, err
+			}
+			current.text = text
+			current.phrase = true
+			i = next
+			out = append(out, current)
+			continue
+		}
+
+		start := i
+		for i < len(input) && !is_separator(input[i]) && input[i] != '"' {
+			i++
+		}
+		word := string(input[start:i])
+
+		if word == "OR" && !current.negated {
+			current.or = true
+			out = append(out, current)
+			continue
+		}
+
+		field, value, found := strings.Cut(word, ":")
+		if found && (field == "actor" || field == "director" || field == "year") {
+			current.field = field
+			current.text = value
+			if value == "" && i < len(input) && input[i] == '"' {
+				text, next, err := read_quoted(input, i)
+				if err != nil {
+					return nil, err
+				}
+				current.text = text
+				i = next
+			}
+			if strings.TrimSpace(current.text) == "" {
+				return nil, parse_error(current.position, "%s: needs a value", field)
+			}
+			out = append(out, current)
+			continue
+		}
+
+		current.text = word
+		out = append(out, current)
+	}
+	return out, nil
+}
+
+func parse_year(value string, position int) (int, error) {
+	year, err := strconv.Atoi(value)
+	if err != nil || year < 1 || year > 9999 {
+		return 0, parse_error(position, "year must be a number like 1999")
+	}
+	return year, nil
+}
+
+func (self *Query) add_year(current term) error {
+	if current.negated {
+		return parse_error(current.position, "year: can not be excluded")
+	}
+	if self.YearFrom != 0 || self.YearTo != 0 {
+		return parse_error(current.position, "year: can be given only once")
+	}
+	from, to, is_range := strings.Cut(current.text, "..")
+	if !is_range {
+		year, err := parse_year(from, current.position)
+		if err != nil {
+			return err
+		}
+		self.YearFrom, self.YearTo = year, year
+		return nil
+	}
+	if from == "" && to == "" {
+		return parse_error(current.position, "year range needs at least one bound")
+	}
+	var err error
+	if from != "" {
+		if self.YearFrom, err = parse_year(from, current.position); err != nil {
+			return err
+		}
+	}
+	if to != "" {
+		if self.YearTo, err = parse_year(to, current.position); err != nil {
+			return err
+		}
+	}
+	if self.YearFrom != 0 && self.YearTo != 0 && self.YearFrom > self.YearTo {
+		return parse_error(current.position, "year range starts after it ends")
+	}
+	return nil
+}
+
+func quote_lexeme(word string) string {
+	word = strings.ReplaceAll(word, `\`, `\\`)
+	word = strings.ReplaceAll(word, `'`, `''`)
+	return "'" + word + "'"
+}
+
+// compile_term turns a word or a phrase into tsquery text
+func compile_term(current term) (string, []string) {
+	var out string
+	words := strings.Fields(current.text)
+	if current.phrase {
+		var lexemes []string
+		for _, word := range words {
+			lexemes = append(lexemes, quote_lexeme(word))
+		}
+		out = "(" + strings.Join(lexemes, " <-> ") + ")"
+	} else {
+		out = quote_lexeme(current.text) + ":*"
+	}
+	if current.negated {
+		return "!" + out, nil
+	}
+	return out, words
+}
+
+// Parse parses the search query. Errors are of type *ParseError.
+func Parse(input string) (Query, error) {
+	var out Query
+	terms, err := tokenize([]rune(input))
+	if err != nil {
+		return out, err
+	}
+
+	var groups [][]term
+	var group []term
+	for i, current := range terms {
+		if !current.or {
+			group = append(group, current)
+			continue
+		}
+		if len(group) == 0 || i == len(terms)-1 {
+			return out, parse_error(current.position, "OR must be placed between two terms")
+		}
+		groups = append(groups, group)
+		group = nil
+	}
+	groups = append(groups, group)
+
+	var compiled []string
+	for _, group := range groups {
+		var parts []string
+		for _, current := range group {
+			switch current.field {
+			case "actor", "director", "year":
+				if len(groups) > 1 {
+					return out, parse_error(current.position, "%s: can not be combined with OR", current.field)
+				}
+				switch {
+				case current.field == "year":
+					if err := out.add_year(current); err != nil {
+						return out, err
+					}
+				case current.field == "director" && current.negated:
+					out.ExcludedDirectors = append(out.ExcludedDirectors, current.text)
+				case current.field == "director":
+					out.Directors = append(out.Directors, current.text)
+				case current.negated:
+					out.ExcludedActors = append(out.ExcludedActors, current.text)
+				default:
+					out.Actors = append(out.Actors, current.text)
+				}
+				continue
+			}
+			if current.phrase && strings.TrimSpace(current.text) == "" {
+				return out, parse_error(current.position, "empty phrase")
+			}
+			part, words := compile_term(current)
+			parts = append(parts, part)
+			out.Words = append(out.Words, words...)
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if len(groups) > 1 && len(parts) > 1 {
+			compiled = append(compiled, "("+strings.Join(parts, " & ")+")")
+		} else {
+			compiled = append(compiled, strings.Join(parts, " & "))
+		}
+	}
+	out.TSQuery = strings.Join(compiled, " | ")
+
+	if out.TSQuery == "" && !out.HasFilters() {
+		return out, &ParseError{Position: 1, Message: "query is empty"}
+	}
+	return out, nil
+}
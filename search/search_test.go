@@ -0,0 +1,136 @@
+package search
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  Query
+	}{
+		{
+			name:  "word",
+			input: "matrix",
+			want:  Query{TSQuery: "'matrix':*", Words: []string{"matrix"}},
+		},
+		{
+			name:  "single quote",
+			input: "it's",
+			want:  Query{TSQuery: "'it''s':*", Words: []string{"it's"}},
+		},
+		{
+			name:  "backslash",
+			input: `back\slash`,
+			want:  Query{TSQuery: `'back\\slash':*`, Words: []string{`back\slash`}},
+		},
+		{
+			name:  "quotes in phrase",
+			input: `"rock 'n' roll"`,
+			want:  Query{TSQuery: "('rock' <-> '''n''' <-> 'roll')", Words: []string{"rock", "'n'", "roll"}},
+		},
+		{
+			name:  "negated word",
+			input: "matrix -reloaded",
+			want:  Query{TSQuery: "'matrix':* & !'reloaded':*", Words: []string{"matrix"}},
+		},
+		{
+			name:  "or",
+			input: "matrix OR alien",
+			want:  Query{TSQuery: "'matrix':* | 'alien':*", Words: []string{"matrix", "alien"}},
+		},
+		{
+			name:  "or binds weaker than and",
+			input: "dark knight OR alien",
+			want:  Query{TSQuery: "('dark':* & 'knight':*) | 'alien':*", Words: []string{"dark", "knight", "alien"}},
+		},
+		{
+			name:  "field filters",
+			input: `matrix actor:keanu -actor:"hugo weaving" director:wachowski -director:nolan`,
+			want: Query{
+				TSQuery:           "'matrix':*",
+				Words:             []string{"matrix"},
+				Actors:            []string{"keanu"},
+				ExcludedActors:    []string{"hugo weaving"},
+				Directors:         []string{"wachowski"},
+				ExcludedDirectors: []string{"nolan"},
+			},
+		},
+		{
+			name:  "year",
+			input: "year:1999",
+			want:  Query{YearFrom: 1999, YearTo: 1999},
+		},
+		{
+			name:  "year range",
+			input: "matrix year:1990..1999",
+			want:  Query{TSQuery: "'matrix':*", Words: []string{"matrix"}, YearFrom: 1990, YearTo: 1999},
+		},
+		{
+			name:  "year range without end",
+			input: "year:1990..",
+			want:  Query{YearFrom: 1990},
+		},
+		{
+			name:  "year range without start",
+			input: "year:..1999",
+			want:  Query{YearTo: 1999},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Parse(test.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) returned error: %s", test.input, err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", test.input, got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		position int
+		message  string
+	}{
+		{"empty", "  ", 1, "query is empty"},
+		{"unterminated phrase", `"the matrix`, 1, "unterminated quote"},
+		{"unterminated phrase after word", `matrix "reloaded`, 8, "unterminated quote"},
+		{"unterminated field value", `actor:"keanu`, 7, "unterminated quote"},
+		{"empty phrase", `matrix ""`, 8, "empty phrase"},
+		{"dangling minus", "matrix -", 8, "expected a term after -"},
+		{"field without value", "actor: keanu", 1, "actor: needs a value"},
+		{"or at start", "OR matrix", 1, "OR must be placed between two terms"},
+		{"or at end", "matrix OR", 8, "OR must be placed between two terms"},
+		{"or with actor", "matrix OR actor:keanu", 11, "actor: can not be combined with OR"},
+		{"or with excluded actor", "-actor:keanu OR alien", 1, "actor: can not be combined with OR"},
+		{"or with director", "director:nolan OR alien", 1, "director: can not be combined with OR"},
+		{"or with year", "matrix OR alien year:1999", 17, "year: can not be combined with OR"},
+		{"year not a number", "year:nineties", 1, "year must be a number like 1999"},
+		{"year out of range", "year:10000", 1, "year must be a number like 1999"},
+		{"year range without bounds", "year:..", 1, "year range needs at least one bound"},
+		{"reversed year range", "year:1999..1990", 1, "year range starts after it ends"},
+		{"repeated year", "year:1990 year:1999", 11, "year: can be given only once"},
+		{"negated year", "-year:1999", 1, "year: can not be excluded"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Parse(test.input)
+			var parse_err *ParseError
+			if !errors.As(err, &parse_err) {
+				t.Fatalf("Parse(%q) error = %v, want a *ParseError", test.input, err)
+			}
+			if parse_err.Position != test.position || parse_err.Message != test.message {
+				t.Errorf("Parse(%q) error = %q at %d, want %q at %d",
+					test.input, parse_err.Message, parse_err.Position, test.message, test.position)
+			}
+		})
+	}
+}